@@ -0,0 +1,23 @@
+package fiberzerolog
+
+import (
+	"io"
+)
+
+// NewChannelWriter returns a writer sending each log line it receives on the returned channel,
+// with a buffer of buf lines. It is meant for tests consuming the logs of Config.Logger:
+// writes block once the buffer is full until lines are received.
+func NewChannelWriter(buf int) (io.Writer, <-chan []byte) {
+	ch := make(chan []byte, buf)
+	return channelWriter(ch), ch
+}
+
+type channelWriter chan<- []byte
+
+func (w channelWriter) Write(p []byte) (int, error) {
+	// zerolog reuses p once Write returns
+	line := make([]byte, len(p))
+	copy(line, p)
+	w <- line
+	return len(p), nil
+}
@@ -0,0 +1,99 @@
+package fiberzerolog
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// fieldCount is the key of the number of requests a coalesced log line stands for.
+const fieldCount = "count"
+
+// coalescer groups the log lines of the requests with the same key during a Coalesce window.
+type coalescer struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	groups map[string]*coalesced
+	// order is the keys in the order their group was created
+	order []string
+
+	register sync.Once
+	stop     chan struct{}
+	stopped  sync.Once
+}
+
+// coalesced is the first log line of a group and the number of requests in the group.
+type coalesced struct {
+	logger  zerolog.Logger
+	level   zerolog.Level
+	ctx     context.Context
+	message string
+	count   int
+}
+
+// newCoalescer returns a coalescer flushing its groups every window, once registerShutdown ties it to an app.
+func newCoalescer(window time.Duration) *coalescer {
+	return &coalescer{window: window, groups: make(map[string]*coalesced), stop: make(chan struct{})}
+}
+
+// add counts a request of the key group, keeping its log line if it is the first of the group.
+func (co *coalescer) add(key string, logger zerolog.Logger, level zerolog.Level, ctx context.Context, message string) {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+
+	if g, ok := co.groups[key]; ok {
+		g.count++
+		return
+	}
+	co.groups[key] = &coalesced{logger: logger, level: level, ctx: ctx, message: message, count: 1}
+	co.order = append(co.order, key)
+}
+
+// flush emits the log line of every group with its count and starts a new window.
+func (co *coalescer) flush() {
+	co.mu.Lock()
+	groups, order := co.groups, co.order
+	co.groups, co.order = make(map[string]*coalesced, len(groups)), nil
+	co.mu.Unlock()
+
+	for _, key := range order {
+		g := groups[key]
+		emit(g.logger.With().Int(fieldCount, g.count).Logger(), g.level, g.ctx, g.message)
+	}
+}
+
+func (co *coalescer) run() {
+	ticker := time.NewTicker(co.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			co.flush()
+		case <-co.stop:
+			return
+		}
+	}
+}
+
+// close stops the periodic flushes and emits the pending groups.
+func (co *coalescer) close() error {
+	co.stopped.Do(func() {
+		close(co.stop)
+	})
+	co.flush()
+	return nil
+}
+
+// registerShutdown starts the periodic flushes and flushes the pending groups when the app shuts down,
+// once per coalescer.
+func (co *coalescer) registerShutdown(app *fiber.App) {
+	co.register.Do(func() {
+		go co.run()
+		app.Hooks().OnShutdown(co.close)
+	})
+}
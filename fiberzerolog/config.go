@@ -1,45 +1,199 @@
 package fiberzerolog
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"net"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
 )
 
 const (
-	FieldReferer       = "referer"
-	FieldProtocol      = "protocol"
-	FieldPID           = "pid"
-	FieldPort          = "port"
-	FieldIP            = "ip"
-	FieldIPs           = "ips"
-	FieldHost          = "host"
-	FieldPath          = "path"
-	FieldURL           = "url"
-	FieldUserAgent     = "ua"
-	FieldLatency       = "latency"
-	FieldStatus        = "status"
-	FieldResBody       = "resBody"
-	FieldQueryParams   = "queryParams"
-	FieldBody          = "body"
-	FieldBytesReceived = "bytesReceived"
-	FieldBytesSent     = "bytesSent"
-	FieldRoute         = "route"
-	FieldMethod        = "method"
-	FieldRequestID     = "requestId"
-	FieldError         = "error"
-	FieldReqHeaders    = "reqHeaders"
-	FieldResHeaders    = "resHeaders"
-
-	fieldResBody_       = "res_body"
-	fieldQueryParams_   = "query_params"
-	fieldBytesReceived_ = "bytes_received"
-	fieldBytesSent_     = "bytes_sent"
-	fieldRequestID_     = "request_id"
-	fieldReqHeaders_    = "req_headers"
-	fieldResHeaders_    = "res_headers"
+	FieldReferer            = "referer"
+	FieldProtocol           = "protocol"
+	FieldPID                = "pid"
+	FieldPort               = "port"
+	FieldIP                 = "ip"
+	FieldIPs                = "ips"
+	FieldHost               = "host"
+	FieldPath               = "path"
+	FieldURL                = "url"
+	FieldUserAgent          = "ua"
+	FieldLatency            = "latency"
+	FieldStatus             = "status"
+	FieldResBody            = "resBody"
+	FieldQueryParams        = "queryParams"
+	FieldBody               = "body"
+	FieldBytesReceived      = "bytesReceived"
+	FieldBytesSent          = "bytesSent"
+	FieldRoute              = "route"
+	FieldMethod             = "method"
+	FieldRequestID          = "requestId"
+	FieldError              = "error"
+	FieldReqHeaders         = "reqHeaders"
+	FieldResHeaders         = "resHeaders"
+	FieldColo               = "colo"
+	FieldStreamChunks       = "streamChunks"
+	FieldAcceptLanguage     = "acceptLanguage"
+	FieldAppName            = "app"
+	FieldHostname           = "hostname"
+	FieldReqSizeClass       = "reqSizeClass"
+	FieldCorrelationID      = "correlationId"
+	FieldResponseTimeUnix   = "responseTimeUnix"
+	FieldClientVersion      = "clientVersion"
+	FieldBodyHash           = "bodyHash"
+	FieldCacheControl       = "cacheControl"
+	FieldRequestLine        = "requestLine"
+	FieldLatencySeconds     = "latencySeconds"
+	FieldSessionID          = "sessionId"
+	FieldClaims             = "claims"
+	FieldConnRequestNum     = "connRequestNum"
+	FieldEnv                = "env"
+	FieldContentLength      = "contentLength"
+	FieldScheme             = "scheme"
+	FieldForwardedProto     = "forwardedProto"
+	FieldLatencyPretty      = "latencyPretty"
+	FieldTTFB               = "ttfb"
+	FieldBuildVersion       = "buildVersion"
+	FieldOrigin             = "origin"
+	FieldAllowOrigin        = "allowOrigin"
+	FieldReqHeaderCount     = "reqHeaderCount"
+	FieldResHeaderCount     = "resHeaderCount"
+	FieldProtoMajor         = "protoMajor"
+	FieldProtoMinor         = "protoMinor"
+	FieldBytesSentActual    = "bytesSentActual"
+	FieldIdempotencyKey     = "idempotencyKey"
+	FieldLatencyAnomaly     = "latencyAnomaly"
+	FieldCanonicalPath      = "canonicalPath"
+	FieldRouteMethods       = "routeMethods"
+	FieldAuthScheme         = "authScheme"
+	FieldSizeRatio          = "sizeRatio"
+	FieldRateLimitKey       = "rateLimitKey"
+	FieldHostPort           = "hostPort"
+	FieldClientTimezone     = "clientTimezone"
+	FieldBodyType           = "bodyType"
+	FieldDownstreamLatency  = "downstreamLatency"
+	FieldEvent              = "event"
+	FieldAcceptEncoding     = "acceptEncoding"
+	FieldResponseCompressed = "responseCompressed"
+	FieldProxyDuration      = "proxyDuration"
+	FieldClientCertCN       = "clientCertCN"
+	FieldReqTotalBytes      = "reqTotalBytes"
+	FieldParamNames         = "paramNames"
+	FieldResponseError      = "appError"
+	FieldSlowClient         = "slowClient"
+	FieldDownstreamCalls    = "downstreamCalls"
+	FieldFingerprint        = "fingerprint"
+	FieldServerTiming       = "serverTiming"
+	FieldConnID             = "connId"
+	FieldSizeMismatch       = "sizeMismatch"
+	FieldSubdomain          = "subdomain"
+	FieldResponseID         = "responseId"
+	FieldErrorCode          = "errorCode"
+	FieldReplayable         = "replayable"
+	FieldRange              = "range"
+	FieldContentRange       = "contentRange"
+	FieldTenantID           = "tenantId"
+	FieldCacheHit           = "cacheHit"
+	FieldTimings            = "timings"
+	FieldStatusText         = "statusText"
+
+	fieldResBody_            = "res_body"
+	fieldQueryParams_        = "query_params"
+	fieldBytesReceived_      = "bytes_received"
+	fieldBytesSent_          = "bytes_sent"
+	fieldRequestID_          = "request_id"
+	fieldReqHeaders_         = "req_headers"
+	fieldResHeaders_         = "res_headers"
+	fieldStreamChunks_       = "stream_chunks"
+	fieldAcceptLanguage_     = "accept_language"
+	fieldReqSizeClass_       = "req_size_class"
+	fieldCorrelationID_      = "correlation_id"
+	fieldResponseTimeUnix_   = "response_time_unix"
+	fieldClientVersion_      = "client_version"
+	fieldBodyHash_           = "body_hash"
+	fieldCacheControl_       = "cache_control"
+	fieldRequestLine_        = "request_line"
+	fieldLatencySeconds_     = "latency_seconds"
+	fieldSessionID_          = "session_id"
+	fieldConnRequestNum_     = "conn_request_num"
+	fieldContentLength_      = "content_length"
+	fieldForwardedProto_     = "forwarded_proto"
+	fieldLatencyPretty_      = "latency_pretty"
+	fieldBuildVersion_       = "build_version"
+	fieldAllowOrigin_        = "allow_origin"
+	fieldReqHeaderCount_     = "req_header_count"
+	fieldResHeaderCount_     = "res_header_count"
+	fieldProtoMajor_         = "proto_major"
+	fieldProtoMinor_         = "proto_minor"
+	fieldBytesSentActual_    = "bytes_sent_actual"
+	fieldIdempotencyKey_     = "idempotency_key"
+	fieldLatencyAnomaly_     = "latency_anomaly"
+	fieldCanonicalPath_      = "canonical_path"
+	fieldRouteMethods_       = "route_methods"
+	fieldAuthScheme_         = "auth_scheme"
+	fieldSizeRatio_          = "size_ratio"
+	fieldRateLimitKey_       = "rate_limit_key"
+	fieldHostPort_           = "host_port"
+	fieldClientTimezone_     = "client_timezone"
+	fieldBodyType_           = "body_type"
+	fieldDownstreamLatency_  = "downstream_latency"
+	fieldAcceptEncoding_     = "accept_encoding"
+	fieldResponseCompressed_ = "response_compressed"
+	fieldProxyDuration_      = "proxy_duration"
+	fieldClientCertCN_       = "client_cert_cn"
+	fieldReqTotalBytes_      = "req_total_bytes"
+	fieldParamNames_         = "param_names"
+	fieldResponseError_      = "app_error"
+	fieldSlowClient_         = "slow_client"
+	fieldDownstreamCalls_    = "downstream_calls"
+	fieldServerTiming_       = "server_timing"
+	fieldConnID_             = "conn_id"
+	fieldSizeMismatch_       = "size_mismatch"
+	fieldResponseID_         = "response_id"
+	fieldErrorCode_          = "error_code"
+	fieldContentRange_       = "content_range"
+	fieldTenantID_           = "tenant_id"
+	fieldCacheHit_           = "cache_hit"
+	fieldStatusText_         = "status_text"
+
+	fieldErrorDetails   = "errorDetails"
+	fieldErrorDetails_  = "error_details"
+	fieldEventTrimmed   = "eventTrimmed"
+	fieldEventTrimmed_  = "event_trimmed"
+	fieldDeprecated     = "deprecated"
+	fieldLogID          = "logId"
+	fieldLogID_         = "log_id"
+	fieldDeclaredBytes  = "declaredBytes"
+	fieldDeclaredBytes_ = "declared_bytes"
+	fieldAuditID        = "auditId"
+	fieldAuditID_       = "audit_id"
+	fieldSkipReason     = "skipReason"
+	fieldSkipReason_    = "skip_reason"
+)
+
+// FieldType defines how a field value is logged, see Config.FieldTypes.
+type FieldType int
+
+const (
+	FieldTypeString FieldType = iota
+	FieldTypeInt
+	FieldTypeFloat
+	FieldTypeBool
 )
 
 // Config defines the config for middleware.
@@ -49,22 +203,273 @@ type Config struct {
 	// Optional. Default: nil
 	Next func(c *fiber.Ctx) bool
 
+	// EnabledFunc defines a function checked at the start of each request, the middleware is a pass-through
+	// when it returns false, eg: to toggle logging from an admin endpoint without redeploying.
+	// It is called concurrently by the requests, so it must be safe for concurrent use, eg: reading an atomic value.
+	//
+	// Optional. Default: nil (enabled)
+	EnabledFunc func() bool
+
+	// SkipFunc defines a function to skip logging when returned true.
+	// Unlike Next, it runs after the handler so it can inspect the response.
+	//
+	// Optional. Default: nil
+	SkipFunc func(c *fiber.Ctx) bool
+
 	// SkipBody defines a function to skip log  "body" field when returned true.
 	//
 	// Optional. Default: nil
 	SkipBody func(c *fiber.Ctx) bool
 
+	// EmptyBodyPlaceholder defines the value of the "body" and "resBody" fields for empty bodies, eg: "<empty>".
+	// If empty, the fields are omitted for empty bodies.
+	//
+	// Optional. Default: ""
+	EmptyBodyPlaceholder string
+
 	// SkipResBody defines a function to skip log  "resBody" field when returned true.
 	//
 	// Optional. Default: nil
 	SkipResBody func(c *fiber.Ctx) bool
 
+	// CaptureBodyBeforeNext copies the request body before calling the handler,
+	// so the "body" field reflects what the client sent even if the handler mutates it.
+	//
+	// Optional. Default: false
+	CaptureBodyBeforeNext bool
+
+	// ParseJSONBody logs valid JSON request bodies, by their Content-Type, as nested JSON values
+	// instead of strings in the "body" field. Other bodies are logged as strings.
+	//
+	// Optional. Default: false
+	ParseJSONBody bool
+
+	// BodyAuditWriter defines a dedicated writer of the FieldBody and FieldResBody fields, eg: a secure audit log.
+	// When set, they are written there instead of the main event, in a line with the request ID and an
+	// "auditId" the main event logs as a reference. The line is written along with the main event, so only
+	// the bodies of logged requests are written: none for the requests dropped by Sampler or merged by Coalesce.
+	//
+	// Optional. Default: nil
+	BodyAuditWriter io.Writer
+
+	// MaxArrayElements truncates the arrays of the bodies logged by ParseJSONBody, at any depth,
+	// to their first elements, followed by a "...+N more" marker. Other values are unaffected.
+	//
+	// Optional. Default: 0 (unlimited)
+	MaxArrayElements int
+
+	// BodyHashAlgo defines the hash function of the request body hex digest logged by FieldBodyHash.
+	//
+	// Optional. Default: sha256.New
+	BodyHashAlgo func() hash.Hash
+
 	// GetResBody defines a function to get ResBody.
 	//  eg: when use compress middleware, resBody is unreadable. you can set GetResBody func to get readable resBody.
 	//
 	// Optional. Default: nil
 	GetResBody func(c *fiber.Ctx) []byte
 
+	// IgnoreErrors defines a function to omit the "error" field for expected errors when returned true.
+	// The level is still derived from the response status.
+	//
+	// Optional. Default: nil
+	IgnoreErrors func(err error) bool
+
+	// ErrorDetailFunc defines a function to get structured details of an error, eg: field-level validation errors,
+	// logged under "errorDetails" next to the "error" field. Return nil for errors without details.
+	//
+	// Optional. Default: nil
+	ErrorDetailFunc func(err error) interface{}
+
+	// ClientVersionHeader defines the request header FieldClientVersion is read from.
+	// The field is omitted when the header is absent.
+	//
+	// Optional. Default: X-Client-Version
+	ClientVersionHeader string
+
+	// ClientTimezoneHeader defines the request header FieldClientTimezone is read from.
+	// The field is omitted when the header is absent.
+	//
+	// Optional. Default: X-Client-Timezone
+	ClientTimezoneHeader string
+
+	// BaseDomain defines the domain stripped from the host for FieldSubdomain, eg: "example.com"
+	// to log "acme" for acme.example.com. If empty, the subdomains are those of c.Subdomains().
+	// The field is omitted when the host equals the base domain or is not under it.
+	//
+	// Optional. Default: ""
+	BaseDomain string
+
+	// ProxyDurationHeader defines the header FieldProxyDuration is read from: a duration, eg: "12.5ms",
+	// or a number of milliseconds. The response header, eg: copied from a proxied upstream, is checked first,
+	// then the request header. The field is omitted when the header is missing or unparseable.
+	// The response header is ignored when SetResponseTimeHeader sets it, it holds the measured latency then.
+	//
+	// Optional. Default: X-Response-Time
+	ProxyDurationHeader string
+
+	// SetResponseTimeHeader sets the measured latency, eg: "1.234ms", in the ResponseTimeHeader response header.
+	// It is set once the handler chain and error handler have returned, before the response is written,
+	// and also for requests not logged, except those skipped by EnabledFunc, Next or SkipURIs.
+	//
+	// Optional. Default: false
+	SetResponseTimeHeader bool
+
+	// ResponseTimeHeader defines the response header set by SetResponseTimeHeader.
+	//
+	// Optional. Default: X-Response-Time
+	ResponseTimeHeader string
+
+	// IdempotencyHeader defines the request header FieldIdempotencyKey is read from.
+	// The field is omitted when the header is absent.
+	//
+	// Optional. Default: Idempotency-Key
+	IdempotencyHeader string
+
+	// SessionCookieName defines the cookie FieldSessionID is read from.
+	// The field is omitted when the cookie is absent.
+	//
+	// Optional. Default: session_id
+	SessionCookieName string
+
+	// HashSessionID logs the sha256 hex digest of the session ID instead of its value.
+	//
+	// Optional. Default: false
+	HashSessionID bool
+
+	// JWTClaimsFunc defines a function to get the JWT claims logged by FieldClaims.
+	// Only return the claims you want to expose; the field is omitted when the map is empty.
+	//
+	// Optional. Default: nil
+	JWTClaimsFunc func(c *fiber.Ctx) map[string]interface{}
+
+	// TypeMarshalers defines JSON marshalers by type for the values of ErrorDetailFunc and JWTClaimsFunc,
+	// logged as raw JSON without reflection. Values of other types are logged with zerolog's Interface.
+	// The marshalers must return valid JSON.
+	//
+	// Optional. Default: nil
+	TypeMarshalers map[reflect.Type]func(interface{}) []byte
+
+	// RateLimitKeyFunc defines a function to get the key the request is rate limited under for FieldRateLimitKey,
+	//  eg: the KeyGenerator of the limiter middleware. The field is omitted when it is nil or returns an empty string.
+	//
+	// Optional. Default: nil
+	RateLimitKeyFunc func(c *fiber.Ctx) string
+
+	// EnvVar defines the environment variable FieldEnv is read from, once when the middleware is created.
+	// The field is omitted when the variable is unset.
+	//
+	// Optional. Default: APP_ENV
+	EnvVar string
+
+	// BuildVersion defines the version of the running build logged by FieldBuildVersion,
+	//  eg: a version variable set with -ldflags "-X main.version=v1.2.3".
+	// The field is omitted when empty.
+	//
+	// Optional. Default: ""
+	BuildVersion string
+
+	// ColoResolver defines a function to get the data center serving the request for FieldColo.
+	// The field is omitted when it returns an empty string.
+	//
+	// Optional. Default: reads the COLO environment variable once
+	ColoResolver func(c *fiber.Ctx) string
+
+	// FingerprintFunc defines a function to get the request fingerprint logged by FieldFingerprint,
+	// eg: DefaultFingerprint. The field is omitted when it is nil or returns an empty string.
+	//
+	// Optional. Default: nil
+	FingerprintFunc func(c *fiber.Ctx) string
+
+	// TenantResolver defines a function to get the tenant of the request logged by FieldTenantID,
+	// eg: from a header, the subdomain or a JWT claim depending on the route.
+	// The field is omitted when it is nil or returns an empty string.
+	//
+	// Optional. Default: nil
+	TenantResolver func(c *fiber.Ctx) string
+
+	// RequestIDHeader defines the header FieldRequestID is read from.
+	// The response header is checked first, then the request header.
+	//
+	// Optional. Default: X-Request-ID
+	RequestIDHeader string
+
+	// ResponseIDHeader defines the response header FieldResponseID is read from, eg: an ID assigned
+	// by the handler to correlate the response with async systems. The field is omitted when the header is absent.
+	//
+	// Optional. Default: X-Response-ID
+	ResponseIDHeader string
+
+	// ReqSizeBuckets defines the request body size boundaries, in bytes, for FieldReqSizeClass.
+	// Bodies smaller than ReqSizeBuckets[0] are "tiny", smaller than ReqSizeBuckets[1] "small",
+	// smaller than ReqSizeBuckets[2] "medium" and others "large".
+	// It must contain 3 ascending sizes.
+	//
+	// Optional. Default: {1024, 64 * 1024, 1024 * 1024}
+	ReqSizeBuckets []int
+
+	// ContextCorrelationKey defines the c.UserContext() key FieldCorrelationID is read from.
+	// The field is omitted when the value is missing or not a string.
+	//
+	// Optional. Default: nil
+	ContextCorrelationKey interface{}
+
+	// EventNameKey defines the c.Locals() key handlers set the event name logged by FieldEvent under, eg: "user.login".
+	// The field is omitted when the value is missing or not a string.
+	//
+	// Optional. Default: "event"
+	EventNameKey interface{}
+
+	// DownstreamCallsKey defines the c.Locals() key of the counter of downstream calls, eg: SQL or HTTP,
+	// logged by FieldDownstreamCalls. The counter is an int, an int64, or an *int64 updated atomically.
+	// The field is omitted when the value is missing or of another type.
+	//
+	// Optional. Default: "downstreamCalls"
+	DownstreamCallsKey interface{}
+
+	// CacheHitKey defines the c.Locals() key a cache middleware sets to true when it serves the response,
+	// logged by FieldCacheHit. The field is false when the value is missing or not a bool.
+	//
+	// Optional. Default: "cacheHit"
+	CacheHitKey interface{}
+
+	// TimingLocalsKeys defines the c.Locals() keys of the sub-timings of the request, eg: "db" or "cache",
+	// logged by FieldTimings under their key, formatted like FieldLatency. Keys that are missing or
+	// not a time.Duration are skipped, and the field is omitted when all are.
+	//
+	// Optional. Default: nil
+	TimingLocalsKeys []string
+
+	// RedactQueryParams defines the query parameters whose values are masked
+	// in the "queryParams", "url" and "requestLine" fields. The request itself is not modified.
+	//
+	// Optional. Default: nil
+	RedactQueryParams []string
+
+	// DeprecatedRoutes defines the route paths, eg: "/v1/users/:id", whose logs get a "deprecated":true field.
+	//
+	// Optional. Default: nil
+	DeprecatedRoutes []string
+
+	// DeprecatedMinLevel defines the minimum level of the logs of DeprecatedRoutes, eg: zerolog.WarnLevel
+	// to log their successful requests as warnings. zerolog.DebugLevel keeps the levels from Levels.
+	//
+	// Optional. Default: zerolog.DebugLevel
+	DeprecatedMinLevel zerolog.Level
+
+	// AppErrorExtractor defines a function reporting whether the response holds an application error
+	// despite its status, eg: a 200 with an error object in its JSON body, and its message.
+	// The message is logged by FieldResponseError, omitted when there is no application error.
+	//
+	// Optional. Default: nil
+	AppErrorExtractor func(c *fiber.Ctx) (bool, string)
+
+	// AppErrorMinLevel defines the minimum level of the logs of responses with an application error,
+	// see AppErrorExtractor. zerolog.DebugLevel keeps the levels from Levels.
+	//
+	// Optional. Default: zerolog.DebugLevel
+	AppErrorMinLevel zerolog.Level
+
 	// Skip logging for these uri
 	//
 	// Optional. Default: nil
@@ -83,11 +488,92 @@ type Config struct {
 	// Optional. Default: nil
 	GetLogger func(c *fiber.Ctx) zerolog.Logger
 
+	// LevelWriters defines the writers of the request logs by level, as selected by Levels,
+	//  eg: to write error logs to a different sink. Levels without a writer use Logger or GetLogger's.
+	//
+	// Optional. Default: nil
+	LevelWriters map[zerolog.Level]io.Writer
+
+	// Storage buffers the request logs in a Fiber storage, eg: to absorb traffic spikes, instead of writing
+	// them to Logger. The buffered logs are written to AsyncWriter in batches every AsyncFlushInterval and
+	// when the app shuts down. Logs written to LevelWriters are not buffered.
+	// The buffer is drained by the process that wrote it, logs left in the storage by a previous process are not.
+	//
+	// Optional. Default: nil
+	Storage fiber.Storage
+
+	// AsyncWriter defines the writer the logs buffered in Storage are drained to.
+	//
+	// Required with Storage. Default: nil
+	AsyncWriter io.Writer
+
+	// AsyncFlushInterval defines how often the logs buffered in Storage are drained to AsyncWriter.
+	//
+	// Optional. Default: 1 * time.Second
+	AsyncFlushInterval time.Duration
+
+	// ObserveLatency defines a function called with the route path and latency in seconds of each request,
+	//  eg: to observe a metrics histogram. It is called before SkipFunc and the levels,
+	// so requests not logged are observed too, except those skipped by Next or SkipURIs.
+	//
+	// Optional. Default: nil
+	ObserveLatency func(route string, seconds float64)
+
+	// Hooks are added to the request logger, after the hooks of Logger or GetLogger.
+	// They run in order, synchronously before each log line is written, so keep them fast.
+	//
+	// Optional. Default: nil
+	Hooks []zerolog.Hook
+
 	// Add fields what you want see.
 	//
 	// Optional. Default: {"ip", "latency", "status", "method", "url", "error"}
 	Fields []string
 
+	// Decorate defines a function to add fields to the request log with the zerolog API,
+	//  eg: arrays, dicts or typed values not covered by Fields. It is called after the Fields are added.
+	//
+	// Optional. Default: nil
+	Decorate func(c *fiber.Ctx, zc zerolog.Context) zerolog.Context
+
+	// FieldOrder defines the order of the logged field names, eg: {"status", "method", "path"}.
+	// The listed fields come first in this order, others follow in the order they are added.
+	// It applies to the middleware fields, including headers; Logger context fields, the level and the message
+	// are written by zerolog around them, and fields measured while streaming come last.
+	// Every event is buffered and re-encoded, so it is slower: meant for diffing and golden-file tests.
+	//
+	// Optional. Default: nil
+	FieldOrder []string
+
+	// FieldsByStatusClass defines the fields to log by response status class: "1xx", "2xx", "3xx", "4xx" or "5xx",
+	//  eg: to log the bodies and headers of errors only. Classes without fields use Fields.
+	//
+	// Optional. Default: nil
+	FieldsByStatusClass map[string][]string
+
+	// DebugCookie defines the name of a cookie that, when present with a non-empty value, logs the request
+	// at zerolog.TraceLevel, whatever its status, with the fields of Fields and FieldsByStatusClass combined,
+	// eg: for support engineers to get verbose logs of their own session. Logger must allow the trace level.
+	// The cookie is set by the client: anyone knowing its name can get their requests logged in full,
+	// including the configured bodies and headers, and raise the log volume. Use a hard to guess name.
+	//
+	// Optional. Default: ""
+	DebugCookie string
+
+	// RemoveFields defines fields to remove from Fields, or from the default fields if Fields is not set,
+	// and from FieldsByStatusClass.
+	//  eg: {"error"} logs the default fields except the error.
+	//
+	// Optional. Default: nil
+	RemoveFields []string
+
+	// FieldTypes overrides the type string and integer fields are logged with, keyed by the logged field name.
+	// eg: {"port": FieldTypeInt} logs {"port":3000} instead of {"port":"3000"}.
+	// Values that can't be converted are logged with their own type.
+	//
+	// Optional. Default: nil
+	FieldTypes map[string]FieldType
+
 	// Wrap headers to dictionary.
 	// If false: {"method":"POST", "header-key":"header value"}
 	// If true: {"method":"POST", "reqHeaders": {"header-key":"header value"}}
@@ -95,13 +581,125 @@ type Config struct {
 	// Optional. Default: false
 	WrapHeaders bool
 
-	// Use snake case for fields: FieldResBody, FieldQueryParams, FieldBytesReceived, FieldBytesSent, FieldRequestId, FieldReqHeaders, FieldResHeaders.
+	// Use snake case for camelCase fields, eg: FieldResBody, FieldQueryParams, FieldBytesReceived, FieldBytesSent, FieldRequestId, FieldReqHeaders, FieldResHeaders.
 	// If false: {"method":"POST", "resBody":"v", "queryParams":"v"}
 	// If true: {"method":"POST", "res_body":"v", "query_params":"v"}
 	//
 	// Optional. Default: false
 	FieldsSnakeCase bool
 
+	// NestUnderKey nests the fields under a single object with this key, eg: "http".
+	// If empty: {"method":"POST", "status":200}
+	// If "http": {"http": {"method":"POST", "status":200}}
+	//
+	// Optional. Default: ""
+	NestUnderKey string
+
+	// LatencyAnomalyFactor defines how many times slower than the moving average latency of its route
+	// a request must be for FieldLatencyAnomaly to be true.
+	// The average is kept in memory for the first 1024 routes seen, the field is omitted for other routes.
+	//
+	// Optional. Default: 2
+	LatencyAnomalyFactor float64
+
+	// SlowClientThreshold defines how long writing a streamed response body to the client may take,
+	// from its first bytes, before FieldSlowClient is true. See SendStream.
+	//
+	// Optional. Default: 1 * time.Second
+	SlowClientThreshold time.Duration
+
+	// Sampler defines the zerolog sampler of the request logs, eg: &zerolog.BurstSampler{...}.
+	// It applies to the logs allowed by MaxErrorsPerSecond, the middleware has no other sampling.
+	//
+	// Optional. Default: nil
+	Sampler zerolog.Sampler
+
+	// MaxErrorsPerSecond caps the number of logs emitted at error level or above per second.
+	// Every second with suppressed logs, and when the app shuts down, a summary line with their count
+	// in the "suppressed" field is written with Logger, even if no request follows. Other logs are unaffected.
+	//
+	// Optional. Default: 0 (unlimited)
+	MaxErrorsPerSecond int
+
+	// LogOnlyIfSlowerThan only logs the requests whose latency is at least this duration,
+	// eg: to log slow requests in full detail. The fields are assembled once the latency is known,
+	// so faster requests only pay for what is captured before calling the handler, eg: CaptureBodyBeforeNext.
+	//
+	// Optional. Default: 0 (all requests)
+	LogOnlyIfSlowerThan time.Duration
+
+	// Coalesce groups the log lines of the requests with the same method, route and status during this window,
+	// eg: for repetitive traffic. Each group is logged once at the end of the window, and when the app shuts down,
+	// with the line of its first request and the number of requests in the "count" field.
+	// Lines are delayed by up to the window, and the other requests of a group are only counted.
+	//
+	// Optional. Default: 0 (disabled)
+	Coalesce time.Duration
+
+	// MaxEventBytes caps the size of the serialized JSON event, in bytes. Events exceeding it are rebuilt
+	// without their variable-length fields, dropped one at a time in this order until the event fits:
+	// FieldResBody, FieldBody, FieldResHeaders, FieldReqHeaders, FieldQueryParams, FieldURL,
+	// FieldRequestLine, FieldUserAgent, FieldReferer. Trimmed events have "eventTrimmed":true.
+	// Measuring builds every event twice, so only set it when needed.
+	//
+	// Optional. Default: 0 (unlimited)
+	MaxEventBytes int
+
+	// LogID adds a random 16 hex characters ID, unique in the process, to the request log lines
+	// under "logId", eg: to reference a line in a ticket. Unlike the request ID, it differs between the
+	// LogRequestStart and completion lines of a request.
+	//
+	// Optional. Default: false
+	LogID bool
+
+	// WithCaller adds the file:line of the call site that wrote each log line under "caller", with zerolog's Caller,
+	// eg: to tell the lines of this middleware from those of other loggers writing to the same sink.
+	// It is a debugging aid: getting the caller has a runtime cost on every log line.
+	//
+	// Optional. Default: false
+	WithCaller bool
+
+	// DryRun builds every log event as usual but writes it to io.Discard.
+	// It is a profiling aid to measure the cost of the configured fields, not meant for production.
+	//
+	// Optional. Default: false
+	DryRun bool
+
+	// LevelFromResponseHeader defines a response header handlers can set to a level name, eg: "warn",
+	// to override the level derived from Levels. Only "trace", "debug", "info", "warn", "error" and "disabled"
+	// are accepted: "fatal" and "panic" would let a response header stop the server. Other values are ignored.
+	// The header is removed from the response.
+	//
+	// Optional. Default: ""
+	LevelFromResponseHeader string
+
+	// LogRequestStart logs a "Request started" line with the method and url before calling the handler,
+	// at RequestStartLevel. It is not logged for requests skipped by Next or SkipURIs; SkipFunc and the
+	// completion levels run after the handler, so they only apply to the completion line.
+	//
+	// Optional. Default: false
+	LogRequestStart bool
+
+	// RequestStartLevel defines the level of the LogRequestStart line, independent of Levels.
+	//
+	// Optional. Default: zerolog.DebugLevel
+	RequestStartLevel zerolog.Level
+
+	// DebugSkips logs a debug line with the reason, in the "skipReason" field ("skip_reason" with FieldsSnakeCase),
+	// when a request is not logged: "next", "skipURIs", "skipFunc", "logOnlyIfSlowerThan", "level" or "maxErrorsPerSecond".
+	// It is a configuration debugging aid.
+	//
+	// Optional. Default: false
+	DebugSkips bool
+
+	// RecoverConfigFuncs recovers from the panics of the funcs set in this config, eg: GetResBody or SkipBody,
+	// logging a warning with Logger and continuing as if they returned their default result.
+	// Hooks and Sampler are covered too, not the writers (Logger, LevelWriters, AsyncWriter and
+	// BodyAuditWriter) nor Storage. If false, their panics propagate like handler panics.
+	//
+	// Optional. Default: false
+	RecoverConfigFuncs bool
+
 	// Custom response messages.
 	// Response codes >= 500 will be logged with Messages[0].
 	// Response codes >= 400 will be logged with Messages[1].
@@ -121,99 +719,827 @@ type Config struct {
 	//
 	// Optional. Default: {zerolog.ErrorLevel, zerolog.WarnLevel, zerolog.InfoLevel}
 	Levels []zerolog.Level
+
+	// env is the EnvVar value read by New
+	env string
+
+	// latencies is the FieldLatencyAnomaly tracker created by New
+	latencies *latencyTracker
+
+	// routes is the FieldRouteMethods index created by New
+	routes *routeIndex
+
+	// deprecatedRoutes is the DeprecatedRoutes set created by New
+	deprecatedRoutes map[string]struct{}
+
+	// buffer is the Storage buffer created by New
+	buffer *storageBuffer
+
+	// coalescer is the Coalesce groups created by New
+	coalescer *coalescer
+
+	// debugFields is the DebugCookie fields created by New
+	debugFields []string
 }
 
 func (c *Config) loggerCtx(fc *fiber.Ctx) zerolog.Context {
+	var zc zerolog.Context
 	if c.GetLogger != nil {
-		return c.GetLogger(fc).With()
+		zc = c.GetLogger(fc).With()
+	} else {
+		zc = c.Logger.With()
+	}
+
+	if c.WithCaller {
+		zc = zc.Caller()
+	}
+	return zc
+}
+
+// logSkip logs why a request is not logged if DebugSkips is set.
+func (c *Config) logSkip(fc *fiber.Ctx, reason string) {
+	if !c.DebugSkips || c.DryRun {
+		return
+	}
+
+	key := fieldSkipReason
+	if c.FieldsSnakeCase {
+		key = fieldSkipReason_
+	}
+
+	logger := c.loggerCtx(fc).Logger()
+	logger.Debug().Str(key, reason).Str(FieldMethod, fc.Method()).Str(FieldPath, fc.Path()).Msg("Request not logged")
+}
+
+// reqState holds what the middleware measured around the handler chain.
+type reqState struct {
+	// latency includes the error handler, downstream is the handler chain only
+	latency    time.Duration
+	downstream time.Duration
+	err        error
+	// body is the request body captured before the handler, if any
+	body []byte
+	// appError is the AppErrorExtractor message, if hasAppError
+	appError    string
+	hasAppError bool
+	// auditID references the BodyAuditWriter line of the request, if any
+	auditID string
+	// audit writes the BodyAuditWriter line once the main event is emitted, nil in DryRun
+	audit zerolog.Hook
+}
+
+// headerLevel parses a LevelFromResponseHeader level, false for levels a response must not set, eg: fatal.
+func headerLevel(name string) (zerolog.Level, bool) {
+	l, err := zerolog.ParseLevel(name)
+	if err != nil {
+		return l, false
+	}
+	switch l {
+	case zerolog.TraceLevel, zerolog.DebugLevel, zerolog.InfoLevel, zerolog.WarnLevel, zerolog.ErrorLevel, zerolog.Disabled:
+		return l, true
+	}
+	return l, false
+}
+
+// takeLevelHeader returns the LevelFromResponseHeader value and removes the header from the response.
+func (c *Config) takeLevelHeader(fc *fiber.Ctx) string {
+	if c.LevelFromResponseHeader == "" {
+		return ""
 	}
+	name := fc.GetRespHeader(c.LevelFromResponseHeader)
+	fc.Response().Header.Del(c.LevelFromResponseHeader)
+	return name
+}
 
-	return c.Logger.With()
+// passThrough calls the next handlers without logging, the LevelFromResponseHeader header is still removed.
+func (c *Config) passThrough(fc *fiber.Ctx) error {
+	err := fc.Next()
+	c.takeLevelHeader(fc)
+	return err
 }
 
-func (c *Config) logger(fc *fiber.Ctx, latency time.Duration, err error) zerolog.Logger {
+// logStart logs the LogRequestStart line.
+func (c *Config) logStart(fc *fiber.Ctx) {
 	zc := c.loggerCtx(fc)
+	fields := zc
+	if c.NestUnderKey != "" {
+		fields = zerolog.New(nil).With()
+	}
+	fields = fields.Str(FieldMethod, fc.Method()).Str(FieldURL, c.redactURL(fc))
 
-	for _, field := range c.Fields {
-		switch field {
-		case FieldReferer:
-			zc = zc.Str(field, fc.Get(fiber.HeaderReferer))
-		case FieldProtocol:
-			zc = zc.Str(field, fc.Protocol())
-		case FieldPID:
-			zc = zc.Int(field, os.Getpid())
-		case FieldPort:
-			zc = zc.Str(field, fc.Port())
-		case FieldIP:
-			zc = zc.Str(field, fc.IP())
-		case FieldIPs:
-			zc = zc.Str(field, fc.Get(fiber.HeaderXForwardedFor))
-		case FieldHost:
-			zc = zc.Str(field, fc.Hostname())
-		case FieldPath:
-			zc = zc.Str(field, fc.Path())
-		case FieldURL:
-			zc = zc.Str(field, fc.OriginalURL())
-		case FieldUserAgent:
-			zc = zc.Str(field, fc.Get(fiber.HeaderUserAgent))
-		case FieldLatency:
-			zc = zc.Str(field, latency.String())
-		case FieldStatus:
-			zc = zc.Int(field, fc.Response().StatusCode())
-		case FieldResBody:
-			if c.FieldsSnakeCase {
-				field = fieldResBody_
-			}
-			if c.SkipResBody == nil || !c.SkipResBody(fc) {
-				if c.GetResBody == nil {
-					zc = zc.Bytes(field, fc.Response().Body())
-				} else {
-					zc = zc.Bytes(field, c.GetResBody(fc))
-				}
-			}
-		case FieldQueryParams:
-			if c.FieldsSnakeCase {
-				field = fieldQueryParams_
-			}
-			zc = zc.Stringer(field, fc.Request().URI().QueryArgs())
-		case FieldBody:
-			if c.SkipBody == nil || !c.SkipBody(fc) {
-				zc = zc.Bytes(field, fc.Body())
-			}
-		case FieldBytesReceived:
-			if c.FieldsSnakeCase {
-				field = fieldBytesReceived_
-			}
-			zc = zc.Int(field, len(fc.Request().Body()))
-		case FieldBytesSent:
-			if c.FieldsSnakeCase {
-				field = fieldBytesSent_
-			}
-			zc = zc.Int(field, len(fc.Response().Body()))
-		case FieldRoute:
-			zc = zc.Str(field, fc.Route().Path)
-		case FieldMethod:
-			zc = zc.Str(field, fc.Method())
-		case FieldRequestID:
-			if c.FieldsSnakeCase {
-				field = fieldRequestID_
-			}
-			zc = zc.Str(field, fc.GetRespHeader(fiber.HeaderXRequestID))
-		case FieldError:
-			if err != nil {
-				zc = zc.Err(err)
-			}
-		case FieldReqHeaders:
-			if c.FieldsSnakeCase {
-				field = fieldReqHeaders_
-			}
-			if c.WrapHeaders {
-				dict := zerolog.Dict()
-				fc.Request().Header.VisitAll(func(k, v []byte) {
-					dict.Bytes(string(k), v)
-				})
-				zc = zc.Dict(field, dict)
-			} else {
+	emit(c.finish(zc, fields, c.RequestStartLevel), c.RequestStartLevel, fc.UserContext(), "Request started")
+}
+
+// logger returns the request logger context and the context holding the fields,
+// which differ when NestUnderKey is set.
+func (c *Config) logger(fc *fiber.Ctx, st *reqState) (zerolog.Context, zerolog.Context) {
+	zc := c.loggerCtx(fc)
+	if c.NestUnderKey == "" && c.FieldOrder == nil {
+		return zc, c.fields(fc, zc, st)
+	}
+
+	fields := c.fields(fc, zerolog.New(nil).With(), st)
+	if c.FieldOrder != nil {
+		pairs := c.orderFields(fields)
+		if c.NestUnderKey == "" {
+			return zc, zc.Fields(pairs)
+		}
+		fields = zerolog.New(nil).With().Fields(pairs)
+	}
+
+	return zc, fields
+}
+
+// finish returns the request logger for level, with the fields nested under NestUnderKey if set.
+func (c *Config) finish(zc, fields zerolog.Context, level zerolog.Level) zerolog.Logger {
+	l := c.nest(zc, fields)
+
+	if w, ok := c.LevelWriters[level]; ok {
+		l = l.Output(w)
+	} else if c.buffer != nil {
+		l = l.Output(c.buffer)
+	}
+
+	if c.Sampler != nil {
+		l = l.Sample(c.Sampler)
+	}
+
+	if c.LogID {
+		key := fieldLogID
+		if c.FieldsSnakeCase {
+			key = fieldLogID_
+		}
+		l = l.Hook(logIDHook{key: key})
+	}
+
+	for _, hook := range c.Hooks {
+		l = l.Hook(hook)
+	}
+
+	if c.DryRun {
+		l = l.Output(io.Discard)
+	}
+
+	return l
+}
+
+// nest returns the logger holding the fields, nested under NestUnderKey if set.
+func (c *Config) nest(zc, fields zerolog.Context) zerolog.Logger {
+	l := fields.Logger()
+	if c.NestUnderKey != "" {
+		var buf bytes.Buffer
+		nested := l.Output(&buf)
+		nested.Log().Send()
+		l = zc.RawJSON(c.NestUnderKey, bytes.TrimSpace(buf.Bytes())).Logger()
+	}
+	return l
+}
+
+// trimOrder is the order fields are dropped in when an event exceeds MaxEventBytes.
+var trimOrder = [...]string{
+	FieldResBody, FieldBody, FieldResHeaders, FieldReqHeaders,
+	FieldQueryParams, FieldURL, FieldRequestLine, FieldUserAgent, FieldReferer,
+}
+
+// trim drops the trimOrder fields one at a time until the event fits MaxEventBytes,
+// and marks it with "eventTrimmed". The event is still logged if it doesn't fit once they are all dropped.
+func (c *Config) trim(fc *fiber.Ctx, zc, fields zerolog.Context, st *reqState, level zerolog.Level, message string) (zerolog.Context, zerolog.Context) {
+	if c.eventSize(zc, fields, level, message) <= c.MaxEventBytes {
+		return zc, fields
+	}
+
+	key := fieldEventTrimmed
+	if c.FieldsSnakeCase {
+		key = fieldEventTrimmed_
+	}
+
+	t := *c
+	t.Fields = append([]string(nil), c.fieldList(fc)...)
+	// t.Fields is the resolved list, also for DebugCookie requests
+	t.FieldsByStatusClass = nil
+	t.DebugCookie, t.debugFields = "", nil
+	for _, drop := range trimOrder {
+		i := indexOf(t.Fields, drop)
+		if i < 0 {
+			continue
+		}
+		t.Fields = append(t.Fields[:i], t.Fields[i+1:]...)
+		zc, fields = t.logger(fc, st)
+		if c.eventSize(zc, fields.Bool(key, true), level, message) <= c.MaxEventBytes {
+			break
+		}
+	}
+
+	return zc, fields.Bool(key, true)
+}
+
+// eventSize returns the size of the serialized JSON event.
+func (c *Config) eventSize(zc, fields zerolog.Context, level zerolog.Level, message string) int {
+	var buf bytes.Buffer
+	l := c.nest(zc, fields).Output(&buf)
+	l.Log().Str(zerolog.LevelFieldName, level.String()).Msg(message)
+	return buf.Len()
+}
+
+// removeFields returns a copy of fields without the remove fields.
+func removeFields(fields, remove []string) []string {
+	kept := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if indexOf(remove, field) < 0 {
+			kept = append(kept, field)
+		}
+	}
+	return kept
+}
+
+func indexOf(fields []string, field string) int {
+	for i, f := range fields {
+		if f == field {
+			return i
+		}
+	}
+	return -1
+}
+
+// isDeprecated reports whether the matched route is in DeprecatedRoutes.
+func (c *Config) isDeprecated(fc *fiber.Ctx) bool {
+	if c.deprecatedRoutes == nil {
+		return false
+	}
+	_, ok := c.deprecatedRoutes[fc.Route().Path]
+	return ok
+}
+
+// fieldList returns the fields to log for the response status, see FieldsByStatusClass.
+func (c *Config) fieldList(fc *fiber.Ctx) []string {
+	if c.isDebug(fc) {
+		return c.debugFields
+	}
+	if c.FieldsByStatusClass != nil {
+		if fields, ok := c.FieldsByStatusClass[strconv.Itoa(fc.Response().StatusCode()/100)+"xx"]; ok {
+			return fields
+		}
+	}
+	return c.Fields
+}
+
+// isDebug reports whether the request has the DebugCookie.
+func (c *Config) isDebug(fc *fiber.Ctx) bool {
+	return c.DebugCookie != "" && fc.Cookies(c.DebugCookie) != ""
+}
+
+// hasField reports whether field is in Fields or FieldsByStatusClass.
+func (c *Config) hasField(field string) bool {
+	if indexOf(c.Fields, field) >= 0 {
+		return true
+	}
+	for _, fields := range c.FieldsByStatusClass {
+		if indexOf(fields, field) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// fields adds the fields of fieldList to zc, then calls Decorate.
+func (c *Config) fields(fc *fiber.Ctx, zc zerolog.Context, st *reqState) zerolog.Context {
+	latency, err, body := st.latency, st.err, st.body
+	for _, field := range c.fieldList(fc) {
+		switch field {
+		case FieldReferer:
+			zc = c.strField(zc, field, fc.Get(fiber.HeaderReferer))
+		case FieldProtocol:
+			zc = c.strField(zc, field, fc.Protocol())
+		case FieldProtoMajor:
+			if c.FieldsSnakeCase {
+				field = fieldProtoMajor_
+			}
+			if major, _, ok := parseProto(string(fc.Request().Header.Protocol())); ok {
+				zc = c.intField(zc, field, major)
+			}
+		case FieldProtoMinor:
+			if c.FieldsSnakeCase {
+				field = fieldProtoMinor_
+			}
+			if _, minor, ok := parseProto(string(fc.Request().Header.Protocol())); ok {
+				zc = c.intField(zc, field, minor)
+			}
+		case FieldScheme:
+			// Scheme of the connection itself, Protocol may come from a trusted proxy header
+			if fc.Context().IsTLS() {
+				zc = c.strField(zc, field, "https")
+			} else {
+				zc = c.strField(zc, field, "http")
+			}
+		case FieldClientCertCN:
+			// Only verified certificates, eg: with tls.VerifyClientCertIfGiven
+			if c.FieldsSnakeCase {
+				field = fieldClientCertCN_
+			}
+			if state := fc.Context().TLSConnectionState(); state != nil && len(state.VerifiedChains) > 0 {
+				if cn := state.VerifiedChains[0][0].Subject.CommonName; cn != "" {
+					zc = c.strField(zc, field, cn)
+				}
+			}
+		case FieldForwardedProto:
+			if c.FieldsSnakeCase {
+				field = fieldForwardedProto_
+			}
+			if proto := fc.Get(fiber.HeaderXForwardedProto); proto != "" {
+				zc = c.strField(zc, field, proto)
+			}
+		case FieldPID:
+			zc = c.intField(zc, field, os.Getpid())
+		case FieldPort:
+			zc = c.strField(zc, field, fc.Port())
+		case FieldIP:
+			zc = c.strField(zc, field, fc.IP())
+		case FieldIPs:
+			zc = c.strField(zc, field, fc.Get(fiber.HeaderXForwardedFor))
+		case FieldHost:
+			zc = c.strField(zc, field, fc.Hostname())
+		case FieldHostPort:
+			if c.FieldsSnakeCase {
+				field = fieldHostPort_
+			}
+			zc = c.strField(zc, field, hostPort(fc))
+		case FieldSubdomain:
+			if subdomain := c.subdomain(fc); subdomain != "" {
+				zc = c.strField(zc, field, subdomain)
+			}
+		case FieldPath:
+			zc = c.strField(zc, field, fc.Path())
+		case FieldCanonicalPath:
+			if c.FieldsSnakeCase {
+				field = fieldCanonicalPath_
+			}
+			zc = c.strField(zc, field, canonicalPath(fc.Path()))
+		case FieldURL:
+			zc = c.strField(zc, field, c.redactURL(fc))
+		case FieldUserAgent:
+			zc = c.strField(zc, field, fc.Get(fiber.HeaderUserAgent))
+		case FieldLatency:
+			zc = c.strField(zc, field, latency.String())
+		case FieldDownstreamLatency:
+			if c.FieldsSnakeCase {
+				field = fieldDownstreamLatency_
+			}
+			zc = c.strField(zc, field, st.downstream.String())
+		case FieldProxyDuration:
+			if c.FieldsSnakeCase {
+				field = fieldProxyDuration_
+			}
+			var value string
+			// the latency set by SetResponseTimeHeader is not a proxy duration
+			if !c.SetResponseTimeHeader || !strings.EqualFold(c.ResponseTimeHeader, c.ProxyDurationHeader) {
+				value = fc.GetRespHeader(c.ProxyDurationHeader)
+			}
+			if value == "" {
+				value = fc.Get(c.ProxyDurationHeader)
+			}
+			if d, ok := parseProxyDuration(value); ok {
+				zc = c.strField(zc, field, d.String())
+			}
+		case FieldLatencySeconds:
+			if c.FieldsSnakeCase {
+				field = fieldLatencySeconds_
+			}
+			zc = zc.Float64(field, latency.Seconds())
+		case FieldLatencyPretty:
+			if c.FieldsSnakeCase {
+				field = fieldLatencyPretty_
+			}
+			zc = c.strField(zc, field, prettyLatency(latency))
+		case FieldLatencyAnomaly:
+			if c.FieldsSnakeCase {
+				field = fieldLatencyAnomaly_
+			}
+			if anomaly, ok := c.latencyAnomaly(fc, latency); ok {
+				zc = zc.Bool(field, anomaly)
+			}
+		case FieldStatus:
+			zc = c.intField(zc, field, fc.Response().StatusCode())
+		case FieldStatusText:
+			// eg: "Not Found", omitted for unknown codes
+			if c.FieldsSnakeCase {
+				field = fieldStatusText_
+			}
+			if text := utils.StatusMessage(fc.Response().StatusCode()); text != "" {
+				zc = c.strField(zc, field, text)
+			}
+		case FieldResBody:
+			// written to BodyAuditWriter instead, see audit
+			if c.BodyAuditWriter == nil {
+				zc = c.resBodyField(fc, zc)
+			}
+		case FieldQueryParams:
+			if c.FieldsSnakeCase {
+				field = fieldQueryParams_
+			}
+			if query, ok := c.redactQuery(fc); ok {
+				zc = c.strField(zc, field, query)
+			} else {
+				zc = zc.Stringer(field, fc.Request().URI().QueryArgs())
+			}
+		case FieldBody:
+			if c.BodyAuditWriter == nil {
+				zc = c.reqBodyField(fc, zc, body)
+			}
+		case FieldBodyType:
+			if c.FieldsSnakeCase {
+				field = fieldBodyType_
+			}
+			zc = c.strField(zc, field, bodyType(fc.Get(fiber.HeaderContentType), len(fc.Request().Body())))
+		case FieldBytesReceived:
+			if c.FieldsSnakeCase {
+				field = fieldBytesReceived_
+			}
+			zc = c.intField(zc, field, len(fc.Request().Body()))
+		case FieldReqTotalBytes:
+			// The header size is the size of its fasthttp serialization, close to but not exactly what was received
+			if c.FieldsSnakeCase {
+				field = fieldReqTotalBytes_
+			}
+			zc = c.intField(zc, field, len(fc.Request().Header.Header())+len(fc.Request().Body()))
+		case FieldBytesSent:
+			if c.FieldsSnakeCase {
+				field = fieldBytesSent_
+			}
+			zc = c.intField(zc, field, len(fc.Response().Body()))
+		case FieldBytesSentActual:
+			// Streamed responses are counted while written, see streamFields.
+			if streamFrom(fc) == nil {
+				if c.FieldsSnakeCase {
+					field = fieldBytesSentActual_
+				}
+				zc = c.intField(zc, field, len(fc.Response().Body()))
+			}
+		case FieldSizeRatio:
+			// Response to request body size, omitted for empty request bodies
+			if c.FieldsSnakeCase {
+				field = fieldSizeRatio_
+			}
+			if received := len(fc.Request().Body()); received > 0 {
+				zc = zc.Float64(field, float64(len(fc.Response().Body()))/float64(received))
+			}
+		case FieldContentLength:
+			// The advertised length, omitted for chunked streams, unlike FieldBytesSent
+			if c.FieldsSnakeCase {
+				field = fieldContentLength_
+			}
+			status := fc.Response().StatusCode()
+			if n, err := strconv.Atoi(fc.GetRespHeader(fiber.HeaderContentLength)); err == nil && n >= 0 {
+				zc = c.intField(zc, field, n)
+			} else if !fc.Response().IsBodyStream() && status >= 200 && status != fiber.StatusNoContent && status != fiber.StatusNotModified {
+				// fasthttp sets the Content-Length of buffered responses from their body
+				zc = c.intField(zc, field, len(fc.Response().Body()))
+			}
+		case FieldRoute:
+			zc = c.strField(zc, field, fc.Route().Path)
+		case FieldParamNames:
+			// Only the names, eg: ["id"] for /users/:id
+			if c.FieldsSnakeCase {
+				field = fieldParamNames_
+			}
+			params := fc.Route().Params
+			if params == nil {
+				params = []string{}
+			}
+			zc = zc.Strs(field, params)
+		case FieldResponseError:
+			if !st.hasAppError {
+				break
+			}
+			if c.FieldsSnakeCase {
+				field = fieldResponseError_
+			}
+			zc = c.strField(zc, field, st.appError)
+		case FieldRouteMethods:
+			if c.FieldsSnakeCase {
+				field = fieldRouteMethods_
+			}
+			if methods := c.routes.methods(fc); len(methods) > 0 {
+				zc = zc.Strs(field, methods)
+			}
+		case FieldMethod:
+			zc = c.strField(zc, field, fc.Method())
+		case FieldRequestID:
+			if c.FieldsSnakeCase {
+				field = fieldRequestID_
+			}
+			zc = c.strField(zc, field, c.requestID(fc))
+		case FieldErrorCode:
+			// The code the handler returned, the error handler may send another status
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				if c.FieldsSnakeCase {
+					field = fieldErrorCode_
+				}
+				zc = c.intField(zc, field, fiberErr.Code)
+			}
+		case FieldResponseID:
+			if c.FieldsSnakeCase {
+				field = fieldResponseID_
+			}
+			if id := fc.GetRespHeader(c.ResponseIDHeader); id != "" {
+				zc = c.strField(zc, field, id)
+			}
+		case FieldError:
+			if err != nil {
+				zc = zc.Err(err)
+				if c.ErrorDetailFunc != nil {
+					if details := c.ErrorDetailFunc(err); details != nil {
+						key := fieldErrorDetails
+						if c.FieldsSnakeCase {
+							key = fieldErrorDetails_
+						}
+						zc = c.interfaceField(zc, key, details)
+					}
+				}
+			}
+		case FieldColo:
+			if colo := c.ColoResolver(fc); colo != "" {
+				zc = c.strField(zc, field, colo)
+			}
+		case FieldTenantID:
+			if c.TenantResolver != nil {
+				if c.FieldsSnakeCase {
+					field = fieldTenantID_
+				}
+				if tenant := c.TenantResolver(fc); tenant != "" {
+					zc = c.strField(zc, field, tenant)
+				}
+			}
+		case FieldFingerprint:
+			if c.FingerprintFunc != nil {
+				if fingerprint := c.FingerprintFunc(fc); fingerprint != "" {
+					zc = c.strField(zc, field, fingerprint)
+				}
+			}
+		case FieldStreamChunks:
+			// Streamed responses are counted while written, see streamFields.
+			if streamFrom(fc) == nil {
+				if c.FieldsSnakeCase {
+					field = fieldStreamChunks_
+				}
+				zc = c.intField(zc, field, 0)
+			}
+		case FieldOrigin:
+			if origin := fc.Get(fiber.HeaderOrigin); origin != "" {
+				zc = c.strField(zc, field, origin)
+			}
+		case FieldAllowOrigin:
+			if c.FieldsSnakeCase {
+				field = fieldAllowOrigin_
+			}
+			if origin := fc.GetRespHeader(fiber.HeaderAccessControlAllowOrigin); origin != "" {
+				zc = c.strField(zc, field, origin)
+			}
+		case FieldServerTiming:
+			// eg: {"db":53,"app":47.2} for "db;dur=53, app;dur=47.2", the raw value if malformed
+			if c.FieldsSnakeCase {
+				field = fieldServerTiming_
+			}
+			value := string(bytes.Join(fc.Response().Header.PeekAll(fiber.HeaderServerTiming), []byte(",")))
+			if value == "" {
+				break
+			}
+			if metrics, ok := parseServerTiming(value); ok {
+				dict := zerolog.Dict()
+				for _, m := range metrics {
+					dict = dict.Float64(m.name, m.dur)
+				}
+				zc = zc.Dict(field, dict)
+			} else {
+				zc = zc.Str(field, value)
+			}
+		case FieldAcceptLanguage:
+			if c.FieldsSnakeCase {
+				field = fieldAcceptLanguage_
+			}
+			if lang := fc.Get(fiber.HeaderAcceptLanguage); lang != "" {
+				zc = c.strField(zc, field, lang)
+			}
+		case FieldAcceptEncoding:
+			if c.FieldsSnakeCase {
+				field = fieldAcceptEncoding_
+			}
+			if encoding := fc.Get(fiber.HeaderAcceptEncoding); encoding != "" {
+				zc = c.strField(zc, field, encoding)
+			}
+		case FieldResponseCompressed:
+			if c.FieldsSnakeCase {
+				field = fieldResponseCompressed_
+			}
+			encoding := fc.GetRespHeader(fiber.HeaderContentEncoding)
+			zc = zc.Bool(field, encoding != "" && !strings.EqualFold(encoding, "identity"))
+		case FieldAppName:
+			zc = c.strField(zc, field, fc.App().Config().AppName)
+		case FieldHostname:
+			zc = c.strField(zc, field, cachedHostname())
+		case FieldReqSizeClass:
+			if c.FieldsSnakeCase {
+				field = fieldReqSizeClass_
+			}
+			zc = c.strField(zc, field, c.reqSizeClass(len(fc.Request().Body())))
+		case FieldCorrelationID:
+			if c.FieldsSnakeCase {
+				field = fieldCorrelationID_
+			}
+			if c.ContextCorrelationKey != nil {
+				if id, ok := fc.UserContext().Value(c.ContextCorrelationKey).(string); ok {
+					zc = c.strField(zc, field, id)
+				}
+			}
+		case FieldEvent:
+			if name, ok := fc.Locals(c.EventNameKey).(string); ok && name != "" {
+				zc = c.strField(zc, field, name)
+			}
+		case FieldDownstreamCalls:
+			if c.FieldsSnakeCase {
+				field = fieldDownstreamCalls_
+			}
+			switch calls := fc.Locals(c.DownstreamCallsKey).(type) {
+			case int:
+				zc = c.intField(zc, field, calls)
+			case int64:
+				zc = zc.Int64(field, calls)
+			case *int64:
+				if calls != nil {
+					zc = zc.Int64(field, atomic.LoadInt64(calls))
+				}
+			}
+		case FieldCacheHit:
+			if c.FieldsSnakeCase {
+				field = fieldCacheHit_
+			}
+			hit, _ := fc.Locals(c.CacheHitKey).(bool)
+			zc = zc.Bool(field, hit)
+		case FieldTimings:
+			// eg: {"db":"12ms","cache":"150µs"}
+			dict, ok := zerolog.Dict(), false
+			for _, key := range c.TimingLocalsKeys {
+				if d, isDuration := fc.Locals(key).(time.Duration); isDuration {
+					dict, ok = dict.Str(key, d.String()), true
+				}
+			}
+			if ok {
+				zc = zc.Dict(field, dict)
+			}
+		case FieldResponseTimeUnix:
+			if c.FieldsSnakeCase {
+				field = fieldResponseTimeUnix_
+			}
+			zc = zc.Int64(field, time.Now().UnixMilli())
+		case FieldClientVersion:
+			if c.FieldsSnakeCase {
+				field = fieldClientVersion_
+			}
+			if version := fc.Get(c.ClientVersionHeader); version != "" {
+				zc = c.strField(zc, field, version)
+			}
+		case FieldClientTimezone:
+			if c.FieldsSnakeCase {
+				field = fieldClientTimezone_
+			}
+			if tz := fc.Get(c.ClientTimezoneHeader); tz != "" {
+				zc = c.strField(zc, field, tz)
+			}
+		case FieldIdempotencyKey:
+			if c.FieldsSnakeCase {
+				field = fieldIdempotencyKey_
+			}
+			if key := fc.Get(c.IdempotencyHeader); key != "" {
+				zc = c.strField(zc, field, key)
+			}
+		case FieldRange:
+			if r := fc.Get(fiber.HeaderRange); r != "" {
+				zc = c.strField(zc, field, r)
+			}
+		case FieldContentRange:
+			if c.FieldsSnakeCase {
+				field = fieldContentRange_
+			}
+			if r := fc.GetRespHeader(fiber.HeaderContentRange); r != "" {
+				zc = c.strField(zc, field, r)
+			}
+		case FieldReplayable:
+			zc = zc.Bool(field, c.replayable(fc))
+		case FieldBodyHash:
+			if c.FieldsSnakeCase {
+				field = fieldBodyHash_
+			}
+			if body == nil {
+				body = fc.Body()
+			}
+			if len(body) > 0 {
+				h := c.BodyHashAlgo()
+				_, _ = h.Write(body)
+				zc = zc.Hex(field, h.Sum(nil))
+			}
+		case FieldCacheControl:
+			if c.FieldsSnakeCase {
+				field = fieldCacheControl_
+			}
+			if cc := fc.GetRespHeader(fiber.HeaderCacheControl); cc != "" {
+				if dict, ok := parseCacheControl(cc); ok {
+					zc = zc.Dict(field, dict)
+				} else {
+					zc = c.strField(zc, field, cc)
+				}
+			}
+		case FieldRequestLine:
+			if c.FieldsSnakeCase {
+				field = fieldRequestLine_
+			}
+			zc = c.strField(zc, field, fc.Method()+" "+c.redactURL(fc)+" "+string(fc.Request().Header.Protocol()))
+		case FieldAuthScheme:
+			// Only the scheme, never the credentials
+			if c.FieldsSnakeCase {
+				field = fieldAuthScheme_
+			}
+			if scheme, _, ok := strings.Cut(strings.TrimSpace(fc.Get(fiber.HeaderAuthorization)), " "); ok {
+				zc = c.strField(zc, field, scheme)
+			}
+		case FieldSessionID:
+			if c.FieldsSnakeCase {
+				field = fieldSessionID_
+			}
+			if id := fc.Cookies(c.SessionCookieName); id != "" {
+				if c.HashSessionID {
+					sum := sha256.Sum256([]byte(id))
+					zc = zc.Hex(field, sum[:])
+				} else {
+					zc = c.strField(zc, field, id)
+				}
+			}
+		case FieldClaims:
+			if c.JWTClaimsFunc != nil {
+				if claims := c.JWTClaimsFunc(fc); len(claims) > 0 {
+					zc = c.interfaceField(zc, field, claims)
+				}
+			}
+		case FieldRateLimitKey:
+			if c.FieldsSnakeCase {
+				field = fieldRateLimitKey_
+			}
+			if c.RateLimitKeyFunc != nil {
+				if key := c.RateLimitKeyFunc(fc); key != "" {
+					zc = c.strField(zc, field, key)
+				}
+			}
+		case FieldConnRequestNum:
+			// fasthttp counts requests per connection, starting at 1
+			if c.FieldsSnakeCase {
+				field = fieldConnRequestNum_
+			}
+			zc = zc.Uint64(field, fc.Context().ConnRequestNum())
+		case FieldSizeMismatch:
+			// fasthttp sets the Content-Length of other responses from their body, streams are checked in streamFields.
+			if streamFrom(fc) == nil {
+				if c.FieldsSnakeCase {
+					field = fieldSizeMismatch_
+				}
+				zc = zc.Bool(field, false)
+			}
+		case FieldConnID:
+			// fasthttp numbers the accepted connections, see the README for its limits
+			if c.FieldsSnakeCase {
+				field = fieldConnID_
+			}
+			zc = zc.Uint64(field, fc.Context().ConnID())
+		case FieldEnv:
+			if c.env != "" {
+				zc = c.strField(zc, field, c.env)
+			}
+		case FieldBuildVersion:
+			if c.FieldsSnakeCase {
+				field = fieldBuildVersion_
+			}
+			if c.BuildVersion != "" {
+				zc = c.strField(zc, field, c.BuildVersion)
+			}
+		case FieldReqHeaderCount:
+			if c.FieldsSnakeCase {
+				field = fieldReqHeaderCount_
+			}
+			zc = c.intField(zc, field, fc.Request().Header.Len())
+		case FieldResHeaderCount:
+			if c.FieldsSnakeCase {
+				field = fieldResHeaderCount_
+			}
+			zc = c.intField(zc, field, fc.Response().Header.Len())
+		case FieldReqHeaders:
+			if c.FieldsSnakeCase {
+				field = fieldReqHeaders_
+			}
+			if c.WrapHeaders {
+				dict := zerolog.Dict()
+				fc.Request().Header.VisitAll(func(k, v []byte) {
+					dict.Bytes(string(k), v)
+				})
+				zc = zc.Dict(field, dict)
+			} else {
 				fc.Request().Header.VisitAll(func(k, v []byte) {
 					zc = zc.Bytes(string(k), v)
 				})
@@ -236,18 +1562,603 @@ func (c *Config) logger(fc *fiber.Ctx, latency time.Duration, err error) zerolog
 		}
 	}
 
-	return zc.Logger()
+	if c.isDeprecated(fc) {
+		zc = zc.Bool(fieldDeprecated, true)
+	}
+
+	if st.auditID != "" {
+		key := fieldAuditID
+		if c.FieldsSnakeCase {
+			key = fieldAuditID_
+		}
+		zc = zc.Str(key, st.auditID)
+	}
+
+	if c.Decorate != nil {
+		zc = c.Decorate(fc, zc)
+	}
+
+	return zc
+}
+
+// interfaceField adds a field with the TypeMarshalers marshaler of its value type, or zerolog's Interface.
+func (c *Config) interfaceField(zc zerolog.Context, key string, value interface{}) zerolog.Context {
+	if marshal, ok := c.TypeMarshalers[reflect.TypeOf(value)]; ok {
+		// nil when the marshaler panicked, see RecoverConfigFuncs
+		if b := marshal(value); b != nil {
+			return zc.RawJSON(key, b)
+		}
+	}
+	return zc.Interface(key, value)
+}
+
+// strField adds a string field, converted to its FieldTypes type if set.
+func (c *Config) strField(zc zerolog.Context, key, value string) zerolog.Context {
+	t, ok := c.FieldTypes[key]
+	if !ok {
+		return zc.Str(key, value)
+	}
+
+	switch t {
+	case FieldTypeInt:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return zc.Int64(key, n)
+		}
+	case FieldTypeFloat:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return zc.Float64(key, f)
+		}
+	case FieldTypeBool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return zc.Bool(key, b)
+		}
+	}
+	return zc.Str(key, value)
+}
+
+// intField adds an integer field, converted to its FieldTypes type if set.
+func (c *Config) intField(zc zerolog.Context, key string, value int) zerolog.Context {
+	t, ok := c.FieldTypes[key]
+	if !ok {
+		return zc.Int(key, value)
+	}
+
+	switch t {
+	case FieldTypeString:
+		return zc.Str(key, strconv.Itoa(value))
+	case FieldTypeFloat:
+		return zc.Float64(key, float64(value))
+	case FieldTypeBool:
+		return zc.Bool(key, value != 0)
+	}
+	return zc.Int(key, value)
+}
+
+// requestID returns the RequestIDHeader of the response, or else of the request.
+func (c *Config) requestID(fc *fiber.Ctx) string {
+	if id := fc.GetRespHeader(c.RequestIDHeader); id != "" {
+		return id
+	}
+	return fc.Get(c.RequestIDHeader)
+}
+
+// reqBodyField adds the FieldBody field, from body if captured before the handler.
+func (c *Config) reqBodyField(fc *fiber.Ctx, zc zerolog.Context, body []byte) zerolog.Context {
+	if c.SkipBody != nil && c.SkipBody(fc) {
+		return zc
+	}
+
+	if body == nil {
+		body = fc.Body()
+	}
+	if c.ParseJSONBody && bodyType(fc.Get(fiber.HeaderContentType), len(body)) == "json" && json.Valid(body) {
+		if c.MaxArrayElements > 0 {
+			body = truncateArrays(body, c.MaxArrayElements)
+		}
+		return zc.RawJSON(FieldBody, body)
+	}
+	return c.bodyField(zc, FieldBody, body)
+}
+
+// resBodyField adds the FieldResBody field.
+func (c *Config) resBodyField(fc *fiber.Ctx, zc zerolog.Context) zerolog.Context {
+	if c.SkipResBody != nil && c.SkipResBody(fc) {
+		return zc
+	}
+
+	field := FieldResBody
+	if c.FieldsSnakeCase {
+		field = fieldResBody_
+	}
+	if c.GetResBody == nil {
+		return c.bodyField(zc, field, fc.Response().Body())
+	}
+	return c.bodyField(zc, field, c.GetResBody(fc))
+}
+
+// audit renders the BodyAuditWriter line of the body fields of the request, with the request ID and an audit ID
+// logged by the main event as a reference. It sets st.auditID, left "" if no body field is logged, and st.audit.
+func (c *Config) audit(fc *fiber.Ctx, st *reqState) {
+	fieldList := c.fieldList(fc)
+	hasBody, hasResBody := indexOf(fieldList, FieldBody) >= 0, indexOf(fieldList, FieldResBody) >= 0
+	if !hasBody && !hasResBody {
+		return
+	}
+
+	id := newLogID()
+	idKey, requestIDKey := fieldAuditID, FieldRequestID
+	if c.FieldsSnakeCase {
+		idKey, requestIDKey = fieldAuditID_, fieldRequestID_
+	}
+
+	// rendered now, the request may be released once the main event is emitted, eg: by Coalesce
+	var line bytes.Buffer
+	zc := zerolog.New(&line).With().Timestamp().Str(idKey, id)
+	if requestID := c.requestID(fc); requestID != "" {
+		zc = zc.Str(requestIDKey, requestID)
+	}
+	if hasBody {
+		zc = c.reqBodyField(fc, zc, st.body)
+	}
+	if hasResBody {
+		zc = c.resBodyField(fc, zc)
+	}
+
+	l := zc.Logger()
+	l.Log().Send()
+
+	st.auditID = id
+	if !c.DryRun {
+		st.audit = auditHook{w: c.BodyAuditWriter, line: line.Bytes()}
+	}
+}
+
+// auditHook writes a BodyAuditWriter line, see audit.
+type auditHook struct {
+	w    io.Writer
+	line []byte
+}
+
+func (h auditHook) Run(_ *zerolog.Event, _ zerolog.Level, _ string) {
+	_, _ = h.w.Write(h.line)
+}
+
+// bodyField adds a body field, using EmptyBodyPlaceholder for empty bodies.
+func (c *Config) bodyField(zc zerolog.Context, field string, body []byte) zerolog.Context {
+	if len(body) > 0 {
+		return zc.Bytes(field, body)
+	}
+	if c.EmptyBodyPlaceholder != "" {
+		return zc.Str(field, c.EmptyBodyPlaceholder)
+	}
+	return zc
+}
+
+const redacted = "REDACTED"
+
+// redactQuery returns the query string with the RedactQueryParams values masked.
+// It returns false when no parameter needs to be masked.
+func (c *Config) redactQuery(fc *fiber.Ctx) (string, bool) {
+	if len(c.RedactQueryParams) == 0 {
+		return "", false
+	}
+
+	query := fc.Request().URI().QueryArgs()
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+
+	found := false
+	query.VisitAll(func(k, v []byte) {
+		if c.isRedactedQueryParam(k) {
+			found = true
+			args.AddBytesK(k, redacted)
+		} else {
+			args.AddBytesKV(k, v)
+		}
+	})
+	if !found {
+		return "", false
+	}
+
+	return args.String(), true
+}
+
+func (c *Config) isRedactedQueryParam(k []byte) bool {
+	for _, param := range c.RedactQueryParams {
+		if param == string(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL returns the original URL with the RedactQueryParams values masked.
+func (c *Config) redactURL(fc *fiber.Ctx) string {
+	query, ok := c.redactQuery(fc)
+	if !ok {
+		return fc.OriginalURL()
+	}
+
+	path, _, _ := strings.Cut(fc.OriginalURL(), "?")
+	return path + "?" + query
+}
+
+// parseCacheControl parses Cache-Control directives, eg: "max-age=60, no-store" into {"max-age":60, "no-store":true}.
+// It returns false for malformed directives.
+func parseCacheControl(cc string) (*zerolog.Event, bool) {
+	dict := zerolog.Dict()
+	for cc != "" {
+		var directive string
+		directive, cc = nextDirective(cc)
+		directive = strings.TrimSpace(directive)
+		if directive == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(directive, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		switch {
+		case name == "" || strings.ContainsAny(name, "\" \t"):
+			return nil, false
+		case !hasValue:
+			dict = dict.Bool(name, true)
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			dict = dict.Str(name, value[1:len(value)-1])
+		case strings.Contains(value, "\""):
+			return nil, false
+		default:
+			if n, err := strconv.Atoi(value); err == nil {
+				dict = dict.Int(name, n)
+			} else {
+				dict = dict.Str(name, value)
+			}
+		}
+	}
+	return dict, true
+}
+
+// nextDirective splits cc at the first comma outside of a quoted string.
+func nextDirective(cc string) (string, string) {
+	quoted := false
+	for i := 0; i < len(cc); i++ {
+		switch cc[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				return cc[:i], cc[i+1:]
+			}
+		}
+	}
+	return cc, ""
+}
+
+type latencyAnomalyKey struct{}
+
+// latencyAnomaly reports whether latency is an anomaly for the route, see LatencyAnomalyFactor.
+// The result is cached in Locals so the latency is tracked once when events are rebuilt.
+func (c *Config) latencyAnomaly(fc *fiber.Ctx, latency time.Duration) (bool, bool) {
+	if c.latencies == nil {
+		return false, false
+	}
+
+	if anomaly, ok := fc.Locals(latencyAnomalyKey{}).(bool); ok {
+		return anomaly, true
+	}
+
+	anomaly, ok := c.latencies.observe(fc.Method()+" "+fc.Route().Path, latency, c.LatencyAnomalyFactor)
+	if ok {
+		fc.Locals(latencyAnomalyKey{}, anomaly)
+	}
+	return anomaly, ok
+}
+
+// hostPort returns the requested host with its port, eg: "api.example.com:8443".
+// fc.Port is the client port, so the port defaults to the one the request was received on.
+func hostPort(fc *fiber.Ctx) string {
+	host := fc.Hostname()
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+
+	addr, ok := fc.Context().LocalAddr().(*net.TCPAddr)
+	if !ok || addr.Port == 0 {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(addr.Port))
+}
+
+// replayable reports whether the request is safe to retry: its method is idempotent per RFC 9110
+// (GET, HEAD, OPTIONS, TRACE, PUT or DELETE) or it has an IdempotencyHeader key.
+func (c *Config) replayable(fc *fiber.Ctx) bool {
+	switch fc.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodTrace, fiber.MethodPut, fiber.MethodDelete:
+		return true
+	}
+	return fc.Get(c.IdempotencyHeader) != ""
+}
+
+// subdomain returns the labels of the host left of BaseDomain, or of c.Subdomains() without BaseDomain.
+// eg: "acme" for acme.example.com, "" for example.com or other domains.
+func (c *Config) subdomain(fc *fiber.Ctx) string {
+	if c.BaseDomain == "" {
+		return strings.Join(fc.Subdomains(), ".")
+	}
+
+	host := strings.ToLower(fc.Hostname())
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	subdomain := strings.TrimSuffix(host, "."+strings.ToLower(c.BaseDomain))
+	if subdomain == host {
+		return ""
+	}
+	return subdomain
+}
+
+// canonicalPath returns the lowercased path, with repeated slashes collapsed and without trailing slash.
+// eg: "/Users//42/" into "/users/42".
+func canonicalPath(path string) string {
+	var b strings.Builder
+	b.Grow(len(path) + 1)
+	b.WriteByte('/')
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if b.Len() > 1 {
+			b.WriteByte('/')
+		}
+		b.WriteString(strings.ToLower(segment))
+	}
+	return b.String()
+}
+
+// bodyType classifies a request body from its Content-Type:
+//   - "empty" for empty bodies
+//   - "json" for application/json and +json types
+//   - "form" for application/x-www-form-urlencoded
+//   - "multipart" for multipart/* types
+//   - "text" for text/*, application/xml and +xml types
+//   - "binary" for other types, or bodies without Content-Type
+func bodyType(contentType string, size int) string {
+	if size == 0 {
+		return "empty"
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	switch {
+	case mediaType == fiber.MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json"):
+		return "json"
+	case mediaType == fiber.MIMEApplicationForm:
+		return "form"
+	case strings.HasPrefix(mediaType, "multipart/"):
+		return "multipart"
+	case strings.HasPrefix(mediaType, "text/") || mediaType == fiber.MIMEApplicationXML || strings.HasSuffix(mediaType, "+xml"):
+		return "text"
+	default:
+		return "binary"
+	}
+}
+
+// parseProto parses a protocol version, eg: "HTTP/1.1" into 1, 1. "HTTP/2" is parsed into 2, 0.
+func parseProto(proto string) (int, int, bool) {
+	if !strings.HasPrefix(proto, "HTTP/") {
+		return 0, 0, false
+	}
+
+	majorStr, minorStr, hasMinor := strings.Cut(proto[len("HTTP/"):], ".")
+	major, err := strconv.Atoi(majorStr)
+	if err != nil || major < 0 {
+		return 0, 0, false
+	}
+	if !hasMinor {
+		return major, 0, true
+	}
+	minor, err := strconv.Atoi(minorStr)
+	if err != nil || minor < 0 {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// parseProxyDuration parses a duration, eg: "12.5ms", or a number of milliseconds.
+func parseProxyDuration(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if ms, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return d, true
+	}
+	return 0, false
+}
+
+// serverTimingMetric is a Server-Timing metric with its duration in milliseconds, 0 if not set.
+type serverTimingMetric struct {
+	name string
+	dur  float64
+}
+
+// parseServerTiming parses a Server-Timing header value, eg: `db;dur=53, cache;desc="Cache Read";dur=23.2`.
+func parseServerTiming(value string) ([]serverTimingMetric, bool) {
+	var metrics []serverTimingMetric
+	for _, entry := range splitQuoted(value, ',') {
+		params := splitQuoted(entry, ';')
+		m := serverTimingMetric{name: strings.TrimSpace(params[0])}
+		if m.name == "" || strings.ContainsAny(m.name, "\" =") {
+			return nil, false
+		}
+		for _, param := range params[1:] {
+			key, val, _ := strings.Cut(param, "=")
+			if !strings.EqualFold(strings.TrimSpace(key), "dur") {
+				continue
+			}
+			dur, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+			if err != nil {
+				return nil, false
+			}
+			m.dur = dur
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, true
+}
+
+// splitQuoted splits s around sep, except inside double-quoted strings.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// prettyLatency formats d with 2 decimals in µs, ms or s depending on its magnitude, eg: "12.35ms".
+func prettyLatency(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return strconv.FormatFloat(float64(d)/float64(time.Microsecond), 'f', 2, 64) + "µs"
+	case d < time.Second:
+		return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 2, 64) + "ms"
+	default:
+		return strconv.FormatFloat(d.Seconds(), 'f', 2, 64) + "s"
+	}
+}
+
+var reqSizeClasses = [...]string{"tiny", "small", "medium", "large"}
+
+// reqSizeClass returns the ReqSizeBuckets class of a request body size.
+func (c *Config) reqSizeClass(size int) string {
+	for i, bucket := range c.ReqSizeBuckets {
+		if size < bucket {
+			return reqSizeClasses[i]
+		}
+	}
+	return reqSizeClasses[len(reqSizeClasses)-1]
+}
+
+// streamFields adds the fields measured while writing a streamed response body, among fieldList.
+func (c *Config) streamFields(zc zerolog.Context, s *bodyStream, fieldList []string) zerolog.Context {
+	for _, field := range fieldList {
+		switch field {
+		case FieldStreamChunks:
+			if c.FieldsSnakeCase {
+				field = fieldStreamChunks_
+			}
+			zc = zc.Int64(field, atomic.LoadInt64(&s.chunks))
+		case FieldBytesSentActual:
+			if c.FieldsSnakeCase {
+				field = fieldBytesSentActual_
+			}
+			zc = zc.Int64(field, atomic.LoadInt64(&s.bytes))
+		case FieldTTFB:
+			if ttfb, ok := s.ttfb(); ok {
+				zc = c.strField(zc, field, ttfb.String())
+			}
+		case FieldSizeMismatch:
+			// chunked responses have no declared size
+			if s.size < 0 {
+				break
+			}
+			declaredKey, actualKey := fieldDeclaredBytes, FieldBytesSentActual
+			if c.FieldsSnakeCase {
+				field, declaredKey, actualKey = fieldSizeMismatch_, fieldDeclaredBytes_, fieldBytesSentActual_
+			}
+			actual := atomic.LoadInt64(&s.bytes)
+			zc = zc.Bool(field, actual != s.size)
+			if actual != s.size {
+				zc = zc.Int64(declaredKey, s.size)
+				if indexOf(fieldList, FieldBytesSentActual) < 0 {
+					zc = zc.Int64(actualKey, actual)
+				}
+			}
+		case FieldSlowClient:
+			if c.FieldsSnakeCase {
+				field = fieldSlowClient_
+			}
+			if d, ok := s.writeDuration(); ok {
+				zc = zc.Bool(field, d > c.SlowClientThreshold)
+			}
+		}
+	}
+
+	return zc
+}
+
+// hasStreamFields reports whether the fields need the response body stream to be observed.
+func (c *Config) hasStreamFields() bool {
+	return c.hasField(FieldStreamChunks) || c.hasField(FieldTTFB) || c.hasField(FieldBytesSentActual) ||
+		c.hasField(FieldSlowClient) || c.hasField(FieldSizeMismatch)
 }
 
 var logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
 
+var (
+	coloOnce sync.Once
+	colo     string
+)
+
+// coloFromEnv returns the COLO environment variable, read on first use.
+func coloFromEnv(_ *fiber.Ctx) string {
+	coloOnce.Do(func() {
+		colo = os.Getenv("COLO")
+	})
+	return colo
+}
+
+var (
+	hostnameOnce sync.Once
+	hostname     string
+)
+
+// cachedHostname returns os.Hostname, looked up on first use.
+func cachedHostname() string {
+	hostnameOnce.Do(func() {
+		hostname, _ = os.Hostname()
+	})
+	return hostname
+}
+
 // ConfigDefault is the default config
 var ConfigDefault = Config{
-	Next:     nil,
-	Logger:   &logger,
-	Fields:   []string{FieldIP, FieldLatency, FieldStatus, FieldMethod, FieldURL, FieldError},
-	Messages: []string{"Server error", "Client error", "Success"},
-	Levels:   []zerolog.Level{zerolog.ErrorLevel, zerolog.WarnLevel, zerolog.InfoLevel},
+	Next:                 nil,
+	Logger:               &logger,
+	ColoResolver:         coloFromEnv,
+	RequestIDHeader:      fiber.HeaderXRequestID,
+	ResponseIDHeader:     "X-Response-ID",
+	ReqSizeBuckets:       []int{1024, 64 * 1024, 1024 * 1024},
+	ClientVersionHeader:  "X-Client-Version",
+	ClientTimezoneHeader: "X-Client-Timezone",
+	ProxyDurationHeader:  "X-Response-Time",
+	ResponseTimeHeader:   "X-Response-Time",
+	EventNameKey:         "event",
+	DownstreamCallsKey:   "downstreamCalls",
+	CacheHitKey:          "cacheHit",
+	IdempotencyHeader:    "Idempotency-Key",
+	LatencyAnomalyFactor: 2,
+	SlowClientThreshold:  time.Second,
+	AsyncFlushInterval:   time.Second,
+	BodyHashAlgo:         sha256.New,
+	SessionCookieName:    "session_id",
+	EnvVar:               "APP_ENV",
+	Fields:               []string{FieldIP, FieldLatency, FieldStatus, FieldMethod, FieldURL, FieldError},
+	Messages:             []string{"Server error", "Client error", "Success"},
+	Levels:               []zerolog.Level{zerolog.ErrorLevel, zerolog.WarnLevel, zerolog.InfoLevel},
 }
 
 // Helper function to set default values
@@ -269,10 +2180,106 @@ func configDefault(config ...Config) Config {
 		cfg.Logger = ConfigDefault.Logger
 	}
 
+	if cfg.ColoResolver == nil {
+		cfg.ColoResolver = ConfigDefault.ColoResolver
+	}
+
+	if cfg.RequestIDHeader == "" {
+		cfg.RequestIDHeader = ConfigDefault.RequestIDHeader
+	}
+
+	if cfg.ResponseIDHeader == "" {
+		cfg.ResponseIDHeader = ConfigDefault.ResponseIDHeader
+	}
+
+	if cfg.ReqSizeBuckets == nil {
+		cfg.ReqSizeBuckets = ConfigDefault.ReqSizeBuckets
+	}
+
+	if len(cfg.ReqSizeBuckets) != len(reqSizeClasses)-1 {
+		panic("fiberzerolog: ReqSizeBuckets must contain 3 sizes")
+	}
+	for i := 1; i < len(cfg.ReqSizeBuckets); i++ {
+		if cfg.ReqSizeBuckets[i] <= cfg.ReqSizeBuckets[i-1] {
+			panic("fiberzerolog: ReqSizeBuckets must be in ascending order")
+		}
+	}
+
+	if cfg.EventNameKey == nil {
+		cfg.EventNameKey = ConfigDefault.EventNameKey
+	}
+
+	if cfg.DownstreamCallsKey == nil {
+		cfg.DownstreamCallsKey = ConfigDefault.DownstreamCallsKey
+	}
+
+	if cfg.CacheHitKey == nil {
+		cfg.CacheHitKey = ConfigDefault.CacheHitKey
+	}
+
+	if cfg.ClientVersionHeader == "" {
+		cfg.ClientVersionHeader = ConfigDefault.ClientVersionHeader
+	}
+
+	if cfg.LatencyAnomalyFactor == 0 {
+		cfg.LatencyAnomalyFactor = ConfigDefault.LatencyAnomalyFactor
+	}
+
+	if cfg.SlowClientThreshold <= 0 {
+		cfg.SlowClientThreshold = ConfigDefault.SlowClientThreshold
+	}
+
+	if cfg.ClientTimezoneHeader == "" {
+		cfg.ClientTimezoneHeader = ConfigDefault.ClientTimezoneHeader
+	}
+
+	if cfg.ProxyDurationHeader == "" {
+		cfg.ProxyDurationHeader = ConfigDefault.ProxyDurationHeader
+	}
+
+	if cfg.ResponseTimeHeader == "" {
+		cfg.ResponseTimeHeader = ConfigDefault.ResponseTimeHeader
+	}
+
+	if cfg.IdempotencyHeader == "" {
+		cfg.IdempotencyHeader = ConfigDefault.IdempotencyHeader
+	}
+
+	if cfg.Storage != nil && cfg.AsyncWriter == nil {
+		panic("fiberzerolog: Storage requires AsyncWriter")
+	}
+
+	if cfg.AsyncFlushInterval <= 0 {
+		cfg.AsyncFlushInterval = ConfigDefault.AsyncFlushInterval
+	}
+
+	if cfg.BodyHashAlgo == nil {
+		cfg.BodyHashAlgo = ConfigDefault.BodyHashAlgo
+	}
+
+	if cfg.SessionCookieName == "" {
+		cfg.SessionCookieName = ConfigDefault.SessionCookieName
+	}
+
+	if cfg.EnvVar == "" {
+		cfg.EnvVar = ConfigDefault.EnvVar
+	}
+
 	if cfg.Fields == nil {
 		cfg.Fields = ConfigDefault.Fields
 	}
 
+	if len(cfg.RemoveFields) > 0 {
+		cfg.Fields = removeFields(cfg.Fields, cfg.RemoveFields)
+		if cfg.FieldsByStatusClass != nil {
+			byClass := make(map[string][]string, len(cfg.FieldsByStatusClass))
+			for class, fields := range cfg.FieldsByStatusClass {
+				byClass[class] = removeFields(fields, cfg.RemoveFields)
+			}
+			cfg.FieldsByStatusClass = byClass
+		}
+	}
+
 	if cfg.Messages == nil {
 		cfg.Messages = ConfigDefault.Messages
 	}
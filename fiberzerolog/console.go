@@ -0,0 +1,35 @@
+package fiberzerolog
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// consoleParts are the fields WithConsoleWriter writes first, in order, after the time and level.
+var consoleParts = []string{FieldMethod, FieldStatus, FieldURL, FieldPath, FieldLatency}
+
+// WithConsoleWriter returns a logger writing colored human-readable lines to w, for use as Config.Logger
+// in local development, eg: 3:04PM INF GET 200 /users 1.2ms Success ip=127.0.0.1
+//
+// The levels selected by Levels are colored, and the method, status, URL, path and latency fields are
+// written first when logged. Like WithLogfmtWriter it decodes every JSON event: keep JSON in production.
+func WithConsoleWriter(w io.Writer) *zerolog.Logger {
+	parts := append([]string{zerolog.TimestampFieldName, zerolog.LevelFieldName}, consoleParts...)
+	logger := zerolog.New(zerolog.ConsoleWriter{
+		Out:           w,
+		TimeFormat:    time.Kitchen,
+		PartsOrder:    append(parts, zerolog.MessageFieldName),
+		FieldsExclude: consoleParts,
+		FormatFieldValue: func(i interface{}) string {
+			// parts of fields not logged are nil
+			if i == nil {
+				return ""
+			}
+			return fmt.Sprintf("%s", i)
+		},
+	}).With().Timestamp().Logger()
+	return &logger
+}
@@ -0,0 +1,43 @@
+package fiberzerolog
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// latencyEWMAAlpha is the weight of the latest latency in the moving average.
+	latencyEWMAAlpha = 0.1
+	// maxTrackedRoutes bounds the memory of latencyTracker, other routes are not tracked.
+	maxTrackedRoutes = 1024
+)
+
+// latencyTracker keeps an exponentially weighted moving average of the latency per route.
+type latencyTracker struct {
+	mu  sync.Mutex
+	avg map[string]float64
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{avg: make(map[string]float64)}
+}
+
+// observe adds latency to the average of route, and reports whether it exceeds factor times the previous average.
+// It returns false if the route is not tracked.
+func (t *latencyTracker) observe(route string, latency time.Duration, factor float64) (bool, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	l := float64(latency)
+	avg, ok := t.avg[route]
+	if !ok {
+		if len(t.avg) >= maxTrackedRoutes {
+			return false, false
+		}
+		t.avg[route] = l
+		return false, true
+	}
+
+	t.avg[route] = avg + latencyEWMAAlpha*(l-avg)
+	return l > factor*avg, true
+}
@@ -0,0 +1,36 @@
+package fiberzerolog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultFingerprint returns a stable request fingerprint for FingerprintFunc, eg: as a bot detection signal.
+// It is the first 16 hex characters of the sha256 digest of the User-Agent, Accept, Accept-Language
+// and Accept-Encoding headers and the client IP subnet: /24 for IPv4, /64 for IPv6.
+func DefaultFingerprint(c *fiber.Ctx) string {
+	h := sha256.New()
+	for _, header := range [...]string{fiber.HeaderUserAgent, fiber.HeaderAccept, fiber.HeaderAcceptLanguage, fiber.HeaderAcceptEncoding} {
+		h.Write(c.Request().Header.Peek(header))
+		h.Write([]byte{'\n'})
+	}
+	h.Write([]byte(ipSubnet(c.IP())))
+
+	sum := h.Sum(nil)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ipSubnet returns the /24 IPv4 or /64 IPv6 subnet of ip, or ip if it cannot be parsed.
+func ipSubnet(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(64, 128)).String()
+}
@@ -0,0 +1,68 @@
+package fiberzerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// truncateArrays returns the JSON value data with its arrays, at any depth, truncated to their first max elements,
+// followed by a "...+N more" marker. The object keys keep their order. data must be valid JSON.
+func truncateArrays(data []byte, max int) []byte {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return data
+	}
+
+	switch trimmed[0] {
+	case '[':
+		var elems []json.RawMessage
+		if err := json.Unmarshal(trimmed, &elems); err != nil {
+			return data
+		}
+
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		for i, elem := range elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if i == max {
+				buf.WriteString(strconv.Quote("...+" + strconv.Itoa(len(elems)-max) + " more"))
+				break
+			}
+			buf.Write(truncateArrays(elem, max))
+		}
+		buf.WriteByte(']')
+		return buf.Bytes()
+	case '{':
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		if _, err := dec.Token(); err != nil {
+			return data
+		}
+
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		for dec.More() {
+			t, err := dec.Token()
+			if err != nil {
+				return data
+			}
+			key, _ := json.Marshal(t)
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return data
+			}
+			if buf.Len() > 1 {
+				buf.WriteByte(',')
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(truncateArrays(value, max))
+		}
+		buf.WriteByte('}')
+		return buf.Bytes()
+	default:
+		return data
+	}
+}
@@ -0,0 +1,101 @@
+package fiberzerolog
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// errorLimiter caps the number of error logs per second.
+type errorLimiter struct {
+	max    int
+	logger zerolog.Logger
+
+	mu         sync.Mutex
+	window     time.Time
+	count      int
+	suppressed int
+
+	register sync.Once
+	stop     chan struct{}
+	stopped  sync.Once
+}
+
+// newErrorLimiter returns a limiter logging the count of the suppressed logs to logger every second,
+// once registerShutdown ties it to an app.
+func newErrorLimiter(max int, logger zerolog.Logger) *errorLimiter {
+	return &errorLimiter{max: max, logger: logger, stop: make(chan struct{})}
+}
+
+// allow reports whether an error log can be emitted at now.
+func (l *errorLimiter) allow(now time.Time, isError bool) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.window) >= time.Second {
+		l.window = now
+		l.count = 0
+	}
+
+	if !isError {
+		return true
+	}
+
+	if l.count >= l.max {
+		l.suppressed++
+		return false
+	}
+
+	l.count++
+	return true
+}
+
+// takeSuppressed returns the number of logs suppressed since the last call.
+func (l *errorLimiter) takeSuppressed() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	suppressed := l.suppressed
+	l.suppressed = 0
+	return suppressed
+}
+
+// summarize logs the summary line of the suppressed logs, if any.
+func (l *errorLimiter) summarize() {
+	if suppressed := l.takeSuppressed(); suppressed > 0 {
+		l.logger.Error().Int("suppressed", suppressed).Msg("Error logs suppressed")
+	}
+}
+
+func (l *errorLimiter) run() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.summarize()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// close stops the periodic summaries after logging the last one.
+func (l *errorLimiter) close() error {
+	l.stopped.Do(func() {
+		close(l.stop)
+	})
+	l.summarize()
+	return nil
+}
+
+// registerShutdown starts the summaries and stops them when the app shuts down, once per limiter.
+func (l *errorLimiter) registerShutdown(app *fiber.App) {
+	l.register.Do(func() {
+		go l.run()
+		app.Hooks().OnShutdown(l.close)
+	})
+}
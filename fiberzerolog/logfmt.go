@@ -0,0 +1,47 @@
+package fiberzerolog
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// WithLogfmtWriter returns a logger writing logfmt-like key=value lines to w, for use as Config.Logger.
+// eg: time=2024-01-02T15:04:05Z level=info message=Success method=GET status=200
+//
+// It is built on zerolog.ConsoleWriter, which decodes every JSON event: prefer JSON where throughput matters.
+func WithLogfmtWriter(w io.Writer) *zerolog.Logger {
+	logger := zerolog.New(zerolog.ConsoleWriter{
+		Out:        w,
+		NoColor:    true,
+		PartsOrder: []string{zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.MessageFieldName},
+		FormatTimestamp: func(i interface{}) string {
+			return logfmtPair(zerolog.TimestampFieldName, i)
+		},
+		FormatLevel: func(i interface{}) string {
+			return logfmtPair(zerolog.LevelFieldName, i)
+		},
+		FormatMessage: func(i interface{}) string {
+			return logfmtPair(zerolog.MessageFieldName, i)
+		},
+	}).With().Timestamp().Logger()
+	return &logger
+}
+
+// logfmtPair formats a key=value pair, quoting the value if needed. It returns "" for nil values.
+func logfmtPair(key string, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	s := fmt.Sprint(value)
+	if s == "" || strings.IndexFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '"' || r == '=' || r == '\\' || r > '~'
+	}) >= 0 {
+		s = strconv.Quote(s)
+	}
+	return key + "=" + s
+}
@@ -0,0 +1,43 @@
+package fiberzerolog
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	logIDSeed    = newLogIDSeed()
+	logIDCounter uint64
+)
+
+func newLogIDSeed() uint64 {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return binary.BigEndian.Uint64(b[:])
+}
+
+// newLogID returns a 16 hex characters ID, unique in the process.
+// It mixes a counter with splitmix64 so IDs look random without locking.
+func newLogID() string {
+	z := logIDSeed + atomic.AddUint64(&logIDCounter, 1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], z)
+	return hex.EncodeToString(b[:])
+}
+
+// logIDHook adds a newLogID to every event, see LogID.
+type logIDHook struct {
+	key string
+}
+
+func (h logIDHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	e.Str(h.key, newLogID())
+}
@@ -0,0 +1,54 @@
+package fiberzerolog
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+)
+
+// orderFields returns the fields of fields as key/value pairs sorted by FieldOrder, for zerolog.Context.Fields.
+// Unlisted fields keep their order after the listed ones.
+func (c *Config) orderFields(fields zerolog.Context) []interface{} {
+	var buf bytes.Buffer
+	l := fields.Logger().Output(&buf)
+	l.Log().Send()
+
+	type pair struct {
+		key   string
+		value json.RawMessage
+	}
+	var parsed []pair
+
+	dec := json.NewDecoder(&buf)
+	if _, err := dec.Token(); err != nil {
+		return nil
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		key, _ := t.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil
+		}
+		parsed = append(parsed, pair{key, value})
+	}
+
+	pairs := make([]interface{}, 0, 2*len(parsed))
+	for _, key := range c.FieldOrder {
+		for _, p := range parsed {
+			if p.key == key {
+				pairs = append(pairs, p.key, p.value)
+			}
+		}
+	}
+	for _, p := range parsed {
+		if indexOf(c.FieldOrder, p.key) < 0 {
+			pairs = append(pairs, p.key, p.value)
+		}
+	}
+	return pairs
+}
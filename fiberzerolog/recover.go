@@ -0,0 +1,168 @@
+package fiberzerolog
+
+import (
+	"crypto/sha256"
+	"hash"
+	"reflect"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// recoverFuncs wraps the user-supplied funcs to recover from their panics, see RecoverConfigFuncs.
+func (c *Config) recoverFuncs() {
+	noFallback := func(*fiber.Ctx) bool { return false }
+	c.Next = recoverFunc(c, "Next", c.Next, noFallback)
+	c.SkipFunc = recoverFunc(c, "SkipFunc", c.SkipFunc, noFallback)
+	c.SkipBody = recoverFunc(c, "SkipBody", c.SkipBody, noFallback)
+	c.SkipResBody = recoverFunc(c, "SkipResBody", c.SkipResBody, noFallback)
+	c.GetResBody = recoverFunc(c, "GetResBody", c.GetResBody, func(fc *fiber.Ctx) []byte {
+		return fc.Response().Body()
+	})
+	c.IgnoreErrors = recoverFunc(c, "IgnoreErrors", c.IgnoreErrors, func(error) bool { return false })
+	c.ErrorDetailFunc = recoverFunc(c, "ErrorDetailFunc", c.ErrorDetailFunc, func(error) interface{} { return nil })
+	c.JWTClaimsFunc = recoverFunc(c, "JWTClaimsFunc", c.JWTClaimsFunc, func(*fiber.Ctx) map[string]interface{} { return nil })
+	c.RateLimitKeyFunc = recoverFunc(c, "RateLimitKeyFunc", c.RateLimitKeyFunc, func(*fiber.Ctx) string { return "" })
+	c.ColoResolver = recoverFunc(c, "ColoResolver", c.ColoResolver, func(*fiber.Ctx) string { return "" })
+	c.FingerprintFunc = recoverFunc(c, "FingerprintFunc", c.FingerprintFunc, func(*fiber.Ctx) string { return "" })
+	c.TenantResolver = recoverFunc(c, "TenantResolver", c.TenantResolver, func(*fiber.Ctx) string { return "" })
+	c.GetLogger = recoverFunc(c, "GetLogger", c.GetLogger, func(*fiber.Ctx) zerolog.Logger { return *c.Logger })
+
+	if enabled := c.EnabledFunc; enabled != nil {
+		c.EnabledFunc = func() (ok bool) {
+			defer func() {
+				if c.warnPanic("EnabledFunc", recover()) {
+					ok = true
+				}
+			}()
+			return enabled()
+		}
+	}
+
+	if appErrorExtractor := c.AppErrorExtractor; appErrorExtractor != nil {
+		c.AppErrorExtractor = func(fc *fiber.Ctx) (ok bool, message string) {
+			defer func() {
+				if c.warnPanic("AppErrorExtractor", recover()) {
+					ok, message = false, ""
+				}
+			}()
+			return appErrorExtractor(fc)
+		}
+	}
+
+	if len(c.TypeMarshalers) > 0 {
+		// a copy, the map is the caller's
+		marshalers := make(map[reflect.Type]func(interface{}) []byte, len(c.TypeMarshalers))
+		for t, marshal := range c.TypeMarshalers {
+			marshal := marshal
+			// nil makes interfaceField fall back to Interface
+			marshalers[t] = func(v interface{}) (b []byte) {
+				defer func() {
+					if c.warnPanic("TypeMarshalers", recover()) {
+						b = nil
+					}
+				}()
+				return marshal(v)
+			}
+		}
+		c.TypeMarshalers = marshalers
+	}
+
+	if observeLatency := c.ObserveLatency; observeLatency != nil {
+		c.ObserveLatency = func(route string, seconds float64) {
+			defer func() {
+				c.warnPanic("ObserveLatency", recover())
+			}()
+			observeLatency(route, seconds)
+		}
+	}
+
+	if decorate := c.Decorate; decorate != nil {
+		c.Decorate = func(fc *fiber.Ctx, zc zerolog.Context) (result zerolog.Context) {
+			defer func() {
+				if c.warnPanic("Decorate", recover()) {
+					result = zc
+				}
+			}()
+			return decorate(fc, zc)
+		}
+	}
+
+	if len(c.Hooks) > 0 {
+		// a copy, the slice is the caller's
+		hooks := make([]zerolog.Hook, len(c.Hooks))
+		for i, hook := range c.Hooks {
+			hooks[i] = recoverHook{c: c, hook: hook}
+		}
+		c.Hooks = hooks
+	}
+
+	if c.Sampler != nil {
+		c.Sampler = recoverSampler{c: c, sampler: c.Sampler}
+	}
+
+	if bodyHashAlgo := c.BodyHashAlgo; bodyHashAlgo != nil {
+		c.BodyHashAlgo = func() (h hash.Hash) {
+			defer func() {
+				if c.warnPanic("BodyHashAlgo", recover()) {
+					h = sha256.New()
+				}
+			}()
+			return bodyHashAlgo()
+		}
+	}
+}
+
+// recoverFunc returns f recovering from its panics with the fallback result, nil if f is nil.
+func recoverFunc[T, R any](c *Config, name string, f, fallback func(T) R) func(T) R {
+	if f == nil {
+		return nil
+	}
+
+	return func(arg T) (result R) {
+		defer func() {
+			if c.warnPanic(name, recover()) {
+				result = fallback(arg)
+			}
+		}()
+		return f(arg)
+	}
+}
+
+// recoverHook is a Hooks entry recovering from its panics, the event is logged as the hook left it.
+type recoverHook struct {
+	c    *Config
+	hook zerolog.Hook
+}
+
+func (h recoverHook) Run(e *zerolog.Event, level zerolog.Level, message string) {
+	defer func() {
+		h.c.warnPanic("Hooks", recover())
+	}()
+	h.hook.Run(e, level, message)
+}
+
+// recoverSampler is a Sampler recovering from its panics, the event is logged then.
+type recoverSampler struct {
+	c       *Config
+	sampler zerolog.Sampler
+}
+
+func (s recoverSampler) Sample(level zerolog.Level) (ok bool) {
+	defer func() {
+		if s.c.warnPanic("Sampler", recover()) {
+			ok = true
+		}
+	}()
+	return s.sampler.Sample(level)
+}
+
+// warnPanic logs a warning if r is a recovered panic of the name func, and reports whether it is.
+func (c *Config) warnPanic(name string, r interface{}) bool {
+	if r == nil {
+		return false
+	}
+
+	c.Logger.Warn().Str("func", name).Interface("panic", r).Msg("Config func panicked")
+	return true
+}
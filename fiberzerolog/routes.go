@@ -0,0 +1,40 @@
+package fiberzerolog
+
+import (
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// routeIndex maps the routes registered when it is first used to the methods registered for their path.
+type routeIndex struct {
+	once sync.Once
+	// byHandler is keyed by the first handler of the routes, shared by fiber.Ctx.Route and fiber.App.GetRoutes.
+	byHandler map[*fiber.Handler][]string
+}
+
+// methods returns the methods registered for the path of the matched route, nil if no route matched.
+func (r *routeIndex) methods(fc *fiber.Ctx) []string {
+	r.once.Do(func() {
+		r.byHandler = make(map[*fiber.Handler][]string)
+
+		routes := fc.App().GetRoutes(true)
+		byPath := make(map[string][]string)
+		for _, route := range routes {
+			if indexOf(byPath[route.Path], route.Method) < 0 {
+				byPath[route.Path] = append(byPath[route.Path], route.Method)
+			}
+		}
+		for _, route := range routes {
+			if len(route.Handlers) > 0 {
+				r.byHandler[&route.Handlers[0]] = byPath[route.Path]
+			}
+		}
+	})
+
+	route := fc.Route()
+	if len(route.Handlers) == 0 {
+		return nil
+	}
+	return r.byHandler[&route.Handlers[0]]
+}
@@ -0,0 +1,146 @@
+package fiberzerolog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// storageKeyPrefix prefixes the keys of the events buffered in Storage.
+const storageKeyPrefix = "fiberzerolog:"
+
+// storageBuffer buffers the log events in a fiber.Storage and drains them in batches to a writer.
+type storageBuffer struct {
+	storage  fiber.Storage
+	w        io.Writer
+	interval time.Duration
+	// prefix is unique per buffer, so buffers sharing a storage don't drain or overwrite each other's events.
+	prefix string
+
+	// mu guards tail, the key of the next event written.
+	mu   sync.Mutex
+	tail uint64
+	// flushMu serializes the flushes and guards head, the key of the next event drained.
+	flushMu sync.Mutex
+	head    uint64
+	// stale is the keys of the drained events whose Delete failed, retried by the next flush.
+	stale []string
+
+	register sync.Once
+	stop     chan struct{}
+	stopped  sync.Once
+}
+
+// newStorageBuffer returns a buffer drained every interval, once registerShutdown ties it to an app.
+func newStorageBuffer(storage fiber.Storage, w io.Writer, interval time.Duration) *storageBuffer {
+	return &storageBuffer{
+		storage:  storage,
+		w:        w,
+		interval: interval,
+		prefix:   storageKeyPrefix + cachedHostname() + ":" + strconv.Itoa(os.Getpid()) + ":" + newLogID() + ":",
+		stop:     make(chan struct{}),
+	}
+}
+
+func (b *storageBuffer) key(n uint64) string {
+	return b.prefix + strconv.FormatUint(n, 10)
+}
+
+func (b *storageBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// zerolog reuses p once Write returns
+	event := make([]byte, len(p))
+	copy(event, p)
+	if err := b.storage.Set(b.key(b.tail), event, 0); err != nil {
+		return 0, err
+	}
+	b.tail++
+	return len(p), nil
+}
+
+// flush drains the events buffered so far to the writer, in a single write.
+// Events are only deleted from the storage once written, failed writes are retried by the next flush.
+// Written events are never written again, the ones whose Delete failed are only deleted again.
+func (b *storageBuffer) flush() error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	stale := b.stale
+	b.stale = nil
+	for i, key := range stale {
+		if err := b.storage.Delete(key); err != nil {
+			b.stale = append(b.stale, stale[i:]...)
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	tail := b.tail
+	b.mu.Unlock()
+
+	if b.head == tail {
+		return nil
+	}
+
+	var batch bytes.Buffer
+	for n := b.head; n < tail; n++ {
+		event, err := b.storage.Get(b.key(n))
+		if err != nil {
+			return err
+		}
+		batch.Write(event)
+	}
+
+	if _, err := b.w.Write(batch.Bytes()); err != nil {
+		return err
+	}
+
+	head := b.head
+	b.head = tail
+	for n := head; n < tail; n++ {
+		if err := b.storage.Delete(b.key(n)); err != nil {
+			for ; n < tail; n++ {
+				b.stale = append(b.stale, b.key(n))
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *storageBuffer) run() {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = b.flush()
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// close stops the periodic flushes and drains the remaining events.
+func (b *storageBuffer) close() error {
+	b.stopped.Do(func() {
+		close(b.stop)
+	})
+	return b.flush()
+}
+
+// registerShutdown starts the periodic flushes and flushes the buffer when the app shuts down, once per buffer.
+func (b *storageBuffer) registerShutdown(app *fiber.App) {
+	b.register.Do(func() {
+		go b.run()
+		app.Hooks().OnShutdown(b.close)
+	})
+}
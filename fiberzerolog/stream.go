@@ -0,0 +1,120 @@
+package fiberzerolog
+
+import (
+	"bufio"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+type bodyStreamKey struct{}
+
+// bodyStream wraps a response body stream to observe how it is written to the client.
+//
+// fasthttp writes streamed bodies after the handler chain returns, so the middleware
+// emits the log line from Close, when the stream has been fully written.
+type bodyStream struct {
+	io.Reader
+	// chunks counts reads, or flushes when the body is set by SendStreamWriter.
+	chunks  int64
+	flushed bool
+	// bytes counts the bytes read to be written to the client.
+	bytes int64
+	// firstByte is the UnixNano time the first bytes were read to be written to the client.
+	firstByte int64
+	// start is the request start time, set by the middleware.
+	start time.Time
+	// size is the declared Content-Length, -1 for chunked responses, set by the middleware.
+	size int64
+	done func()
+}
+
+func (s *bodyStream) Read(p []byte) (int, error) {
+	n, err := s.Reader.Read(p)
+	if n > 0 {
+		atomic.AddInt64(&s.bytes, int64(n))
+		atomic.CompareAndSwapInt64(&s.firstByte, 0, time.Now().UnixNano())
+		if !s.flushed {
+			atomic.AddInt64(&s.chunks, 1)
+		}
+	}
+	return n, err
+}
+
+// ttfb returns the time from the request start to the first byte written, false if nothing was written.
+func (s *bodyStream) ttfb() (time.Duration, bool) {
+	first := atomic.LoadInt64(&s.firstByte)
+	if first == 0 {
+		return 0, false
+	}
+	return time.Duration(first - s.start.UnixNano()), true
+}
+
+// writeDuration returns the time from the first byte written until now, false if nothing was written.
+func (s *bodyStream) writeDuration() (time.Duration, bool) {
+	first := atomic.LoadInt64(&s.firstByte)
+	if first == 0 {
+		return 0, false
+	}
+	return time.Duration(time.Now().UnixNano() - first), true
+}
+
+func (s *bodyStream) Close() error {
+	var err error
+	if rc, ok := s.Reader.(io.Closer); ok {
+		err = rc.Close()
+	}
+	if s.done != nil {
+		s.done()
+		s.done = nil
+	}
+	return err
+}
+
+// streamFrom returns the body stream set by SendStream if it is still the response body.
+func streamFrom(c *fiber.Ctx) *bodyStream {
+	s, ok := c.Locals(bodyStreamKey{}).(*bodyStream)
+	if !ok || c.Response().BodyStream() != s {
+		return nil
+	}
+	return s
+}
+
+// SendStream sets the response body stream like fiber.Ctx.SendStream,
+// allowing the middleware to log how the stream was written (eg: FieldStreamChunks).
+func SendStream(c *fiber.Ctx, stream io.Reader, size ...int) error {
+	s := &bodyStream{Reader: stream}
+	c.Locals(bodyStreamKey{}, s)
+	return c.SendStream(s, size...)
+}
+
+// SendStreamWriter sets the response body stream writer like fasthttp.Response.SetBodyStreamWriter,
+// allowing the middleware to log how the stream was written (eg: FieldStreamChunks).
+func SendStreamWriter(c *fiber.Ctx, sw func(w *bufio.Writer)) error {
+	s := &bodyStream{flushed: true}
+	s.Reader = fasthttp.NewStreamReader(func(w *bufio.Writer) {
+		bw := bufio.NewWriter(&flushWriter{w: w, s: s})
+		sw(bw)
+		_ = bw.Flush()
+	})
+	c.Locals(bodyStreamKey{}, s)
+	return c.SendStream(s)
+}
+
+// flushWriter counts every flush of the handler's writer as a chunk.
+type flushWriter struct {
+	w *bufio.Writer
+	s *bodyStream
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	atomic.AddInt64(&f.s.chunks, 1)
+	n, err := f.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, f.w.Flush()
+}
@@ -0,0 +1,34 @@
+//go:build !windows && !plan9 && !binary_log
+
+package fiberzerolog
+
+import (
+	"log/syslog"
+
+	"github.com/rs/zerolog"
+)
+
+// WithSyslog returns a logger writing to the syslog daemon at addr, for use as Config.Logger.
+// The syslog severity follows the level of each log line, as selected by Config.Levels.
+// If network is empty, it connects to the local syslog daemon.
+func WithSyslog(network, addr, tag string) (*zerolog.Logger, error) {
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := zerolog.New(syslogLevelWriter{zerolog.SyslogLevelWriter(w)})
+	return &logger, nil
+}
+
+// syslogLevelWriter logs trace lines with the debug severity, zerolog drops them otherwise.
+type syslogLevelWriter struct {
+	zerolog.LevelWriter
+}
+
+func (w syslogLevelWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level == zerolog.TraceLevel {
+		level = zerolog.DebugLevel
+	}
+	return w.LevelWriter.WriteLevel(level, p)
+}
@@ -0,0 +1,50 @@
+//go:build !windows && !plan9 && !binary_log
+
+package fiberzerolog
+
+import (
+	"errors"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+func Test_WithSyslog(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	defer conn.Close()
+
+	logger, err := WithSyslog("udp", conn.LocalAddr().String(), "fiberzerolog")
+	utils.AssertEqual(t, nil, err)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: logger,
+		Fields: []string{FieldStatus},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return errors.New("some random error")
+	})
+
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	buf := make([]byte, 1024)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	utils.AssertEqual(t, nil, err)
+
+	msg := string(buf[:n])
+	// LOG_USER|LOG_ERR priority
+	utils.AssertEqual(t, true, strings.HasPrefix(msg, "<11>"))
+	utils.AssertEqual(t, true, strings.Contains(msg, "fiberzerolog"))
+	utils.AssertEqual(t, true, strings.Contains(msg, `"status":500`))
+}
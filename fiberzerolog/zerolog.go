@@ -1,9 +1,15 @@
 package fiberzerolog
 
 import (
+	"context"
+	"io"
+	"os"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 	"github.com/rs/zerolog"
 )
 
@@ -11,6 +17,46 @@ import (
 func New(config ...Config) fiber.Handler {
 	// Set default config
 	cfg := configDefault(config...)
+	cfg.env = os.Getenv(cfg.EnvVar)
+
+	if cfg.RecoverConfigFuncs {
+		cfg.recoverFuncs()
+	}
+
+	observeStream := cfg.hasStreamFields()
+
+	if cfg.hasField(FieldLatencyAnomaly) {
+		cfg.latencies = newLatencyTracker()
+	}
+
+	if cfg.hasField(FieldRouteMethods) {
+		cfg.routes = &routeIndex{}
+	}
+
+	if cfg.Storage != nil {
+		cfg.buffer = newStorageBuffer(cfg.Storage, cfg.AsyncWriter, cfg.AsyncFlushInterval)
+	}
+
+	if cfg.Coalesce > 0 {
+		cfg.coalescer = newCoalescer(cfg.Coalesce)
+	}
+
+	if cfg.DebugCookie != "" {
+		classes := make([]string, 0, len(cfg.FieldsByStatusClass))
+		for class := range cfg.FieldsByStatusClass {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+
+		cfg.debugFields = append([]string(nil), cfg.Fields...)
+		for _, class := range classes {
+			for _, field := range cfg.FieldsByStatusClass[class] {
+				if indexOf(cfg.debugFields, field) < 0 {
+					cfg.debugFields = append(cfg.debugFields, field)
+				}
+			}
+		}
+	}
 
 	// put ignore uri into a map for faster match
 	skipURIs := make(map[string]struct{}, len(cfg.SkipURIs))
@@ -18,22 +64,67 @@ func New(config ...Config) fiber.Handler {
 		skipURIs[uri] = struct{}{}
 	}
 
+	if len(cfg.DeprecatedRoutes) > 0 {
+		cfg.deprecatedRoutes = make(map[string]struct{}, len(cfg.DeprecatedRoutes))
+		for _, route := range cfg.DeprecatedRoutes {
+			cfg.deprecatedRoutes[route] = struct{}{}
+		}
+	}
+
+	var limiter *errorLimiter
+	if cfg.MaxErrorsPerSecond > 0 {
+		summary := *cfg.Logger
+		if cfg.DryRun {
+			summary = summary.Output(io.Discard)
+		}
+		limiter = newErrorLimiter(cfg.MaxErrorsPerSecond, summary)
+	}
+
 	// Return new handler
 	return func(c *fiber.Ctx) error {
+		// Pass through while disabled
+		if cfg.EnabledFunc != nil && !cfg.EnabledFunc() {
+			return cfg.passThrough(c)
+		}
+
 		// Don't execute middleware if Next returns true
 		if cfg.Next != nil && cfg.Next(c) {
-			return c.Next()
+			cfg.logSkip(c, "next")
+			return cfg.passThrough(c)
 		}
 
 		// skip uri
 		if _, ok := skipURIs[c.Path()]; ok {
-			return c.Next()
+			cfg.logSkip(c, "skipURIs")
+			return cfg.passThrough(c)
+		}
+
+		if cfg.buffer != nil {
+			cfg.buffer.registerShutdown(c.App())
+		}
+
+		if cfg.coalescer != nil {
+			cfg.coalescer.registerShutdown(c.App())
+		}
+
+		if limiter != nil {
+			limiter.registerShutdown(c.App())
+		}
+
+		var body []byte
+		if cfg.CaptureBodyBeforeNext {
+			body = utils.CopyBytes(c.Body())
+		}
+
+		if cfg.LogRequestStart {
+			cfg.logStart(c)
 		}
 
 		start := time.Now()
 
 		// Handle request, store err for logging
 		chainErr := c.Next()
+		downstream := time.Since(start)
 		if chainErr != nil {
 			// Manually call error handler
 			if err := c.App().ErrorHandler(c, chainErr); err != nil {
@@ -43,6 +134,35 @@ func New(config ...Config) fiber.Handler {
 
 		latency := time.Since(start)
 
+		// removed before any skip, the header is internal to the app
+		levelName := cfg.takeLevelHeader(c)
+
+		// The response is written once the handlers return
+		if cfg.SetResponseTimeHeader {
+			c.Set(cfg.ResponseTimeHeader, latency.String())
+		}
+
+		if cfg.ObserveLatency != nil {
+			cfg.ObserveLatency(c.Route().Path, latency.Seconds())
+		}
+
+		// Don't log if SkipFunc returns true
+		if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+			cfg.logSkip(c, "skipFunc")
+			return nil
+		}
+
+		// Don't log fast requests
+		if cfg.LogOnlyIfSlowerThan > 0 && latency < cfg.LogOnlyIfSlowerThan {
+			cfg.logSkip(c, "logOnlyIfSlowerThan")
+			return nil
+		}
+
+		// Don't log expected errors
+		if chainErr != nil && cfg.IgnoreErrors != nil && cfg.IgnoreErrors(chainErr) {
+			chainErr = nil
+		}
+
 		status := c.Response().StatusCode()
 
 		index := 0
@@ -61,37 +181,120 @@ func New(config ...Config) fiber.Handler {
 		}
 		level := cfg.Levels[levelIndex]
 
+		var appError string
+		hasAppError := false
+		if cfg.AppErrorExtractor != nil {
+			hasAppError, appError = cfg.AppErrorExtractor(c)
+		}
+
+		if hasAppError && cfg.AppErrorMinLevel > zerolog.DebugLevel && level < cfg.AppErrorMinLevel {
+			level = cfg.AppErrorMinLevel
+		}
+
+		if cfg.DeprecatedMinLevel > zerolog.DebugLevel && level < cfg.DeprecatedMinLevel && cfg.isDeprecated(c) {
+			level = cfg.DeprecatedMinLevel
+		}
+
+		// level set by the handler
+		if levelName != "" {
+			if l, ok := headerLevel(levelName); ok {
+				level = l
+			}
+		}
+
+		// verbose log of the debug session
+		if cfg.isDebug(c) {
+			level = zerolog.TraceLevel
+		}
+
 		// no log
 		if level == zerolog.NoLevel || level == zerolog.Disabled {
+			cfg.logSkip(c, "level")
 			return nil
 		}
 
+		// cap error logs
+		if limiter != nil {
+			if !limiter.allow(time.Now(), level >= zerolog.ErrorLevel && level < zerolog.NoLevel) {
+				cfg.logSkip(c, "maxErrorsPerSecond")
+				return nil
+			}
+		}
+
 		messageIndex := index
 		if messageIndex >= len(cfg.Messages) {
 			messageIndex = len(cfg.Messages) - 1
 		}
 		message := cfg.Messages[messageIndex]
 
-		logger := cfg.logger(c, latency, chainErr)
+		st := &reqState{
+			latency:     latency,
+			downstream:  downstream,
+			err:         chainErr,
+			body:        body,
+			appError:    appError,
+			hasAppError: hasAppError,
+		}
+		if cfg.BodyAuditWriter != nil {
+			cfg.audit(c, st)
+		}
+		zc, fields := cfg.logger(c, st)
+		if cfg.MaxEventBytes > 0 {
+			zc, fields = cfg.trim(c, zc, fields, st, level, message)
+		}
 		ctx := c.UserContext()
 
-		switch level {
-		case zerolog.DebugLevel:
-			logger.Debug().Ctx(ctx).Msg(message)
-		case zerolog.InfoLevel:
-			logger.Info().Ctx(ctx).Msg(message)
-		case zerolog.WarnLevel:
-			logger.Warn().Ctx(ctx).Msg(message)
-		case zerolog.ErrorLevel:
-			logger.Error().Ctx(ctx).Msg(message)
-		case zerolog.FatalLevel:
-			logger.Fatal().Ctx(ctx).Msg(message)
-		case zerolog.PanicLevel:
-			logger.Panic().Ctx(ctx).Msg(message)
-		case zerolog.TraceLevel:
-			logger.Trace().Ctx(ctx).Msg(message)
+		finish := func(fields zerolog.Context) zerolog.Logger {
+			l := cfg.finish(zc, fields, level)
+			if st.audit != nil {
+				l = l.Hook(st.audit)
+			}
+			return l
 		}
 
+		write := emit
+		if cfg.coalescer != nil {
+			key := c.Method() + " " + c.Route().Path + " " + strconv.Itoa(status)
+			write = func(logger zerolog.Logger, level zerolog.Level, ctx context.Context, message string) {
+				cfg.coalescer.add(key, logger, level, ctx, message)
+			}
+		}
+
+		// Streamed bodies are written after the handler returns, log once the stream is done
+		if observeStream {
+			if s := streamFrom(c); s != nil {
+				s.start = start
+				s.size = int64(c.Response().Header.ContentLength())
+				fieldList := cfg.fieldList(c)
+				s.done = func() {
+					write(finish(cfg.streamFields(fields, s, fieldList)), level, ctx, message)
+				}
+				return nil
+			}
+		}
+
+		write(finish(fields), level, ctx, message)
+
 		return nil
 	}
 }
+
+// emit writes the log line at the given level
+func emit(logger zerolog.Logger, level zerolog.Level, ctx context.Context, message string) {
+	switch level {
+	case zerolog.DebugLevel:
+		logger.Debug().Ctx(ctx).Msg(message)
+	case zerolog.InfoLevel:
+		logger.Info().Ctx(ctx).Msg(message)
+	case zerolog.WarnLevel:
+		logger.Warn().Ctx(ctx).Msg(message)
+	case zerolog.ErrorLevel:
+		logger.Error().Ctx(ctx).Msg(message)
+	case zerolog.FatalLevel:
+		logger.Fatal().Ctx(ctx).Msg(message)
+	case zerolog.PanicLevel:
+		logger.Panic().Ctx(ctx).Msg(message)
+	case zerolog.TraceLevel:
+		logger.Trace().Ctx(ctx).Msg(message)
+	}
+}
@@ -1,21 +1,41 @@
 package fiberzerolog
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/gofiber/fiber/v2/utils"
 	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp/fasthttputil"
 )
 
 func Test_GetResBody(t *testing.T) {
@@ -75,7 +95,7 @@ func Test_SkipBody(t *testing.T) {
 	utils.AssertEqual(t, false, ok)
 }
 
-func Test_SkipResBody(t *testing.T) {
+func Test_CaptureBodyBeforeNext(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
@@ -83,26 +103,28 @@ func Test_SkipResBody(t *testing.T) {
 
 	app := fiber.New()
 	app.Use(New(Config{
-		SkipResBody: func(_ *fiber.Ctx) bool {
-			return true
-		},
-		Logger: &logger,
-		Fields: []string{FieldResBody},
+		Logger:                &logger,
+		Fields:                []string{FieldBody},
+		CaptureBodyBeforeNext: true,
 	}))
 
-	body := bytes.NewReader([]byte("this is test"))
-	resp, err := app.Test(httptest.NewRequest("GET", "/", body))
+	app.Put("/", func(c *fiber.Ctx) error {
+		copy(c.Body(), "mutated")
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	body := bytes.NewReader([]byte("original body"))
+	resp, err := app.Test(httptest.NewRequest("PUT", "/", body))
 	utils.AssertEqual(t, nil, err)
-	utils.AssertEqual(t, fiber.StatusNotFound, resp.StatusCode)
+	utils.AssertEqual(t, fiber.StatusNoContent, resp.StatusCode)
 
 	var logs map[string]any
 	_ = json.Unmarshal(buf.Bytes(), &logs)
-	_, ok := logs[FieldResBody]
 
-	utils.AssertEqual(t, false, ok)
+	utils.AssertEqual(t, "original body", logs[FieldBody])
 }
 
-func Test_Logger(t *testing.T) {
+func Test_BodyHash(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
@@ -111,24 +133,35 @@ func Test_Logger(t *testing.T) {
 	app := fiber.New()
 	app.Use(New(Config{
 		Logger: &logger,
+		Fields: []string{FieldBodyHash},
 	}))
 
-	app.Get("/", func(c *fiber.Ctx) error {
-		return errors.New("some random error")
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
 	})
 
-	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	body := []byte("this is test")
+	_, err := app.Test(httptest.NewRequest("POST", "/", bytes.NewReader(body)))
 	utils.AssertEqual(t, nil, err)
-	utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode)
 
 	var logs map[string]any
 	_ = json.Unmarshal(buf.Bytes(), &logs)
 
-	utils.AssertEqual(t, "some random error", logs[FieldError])
-	utils.AssertEqual(t, float64(500), logs[FieldStatus])
+	sum := sha256.Sum256(body)
+	utils.AssertEqual(t, hex.EncodeToString(sum[:]), logs[FieldBodyHash])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("POST", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldBodyHash]
+
+	utils.AssertEqual(t, false, ok)
 }
 
-func Test_Latency(t *testing.T) {
+func Test_EmptyBodyPlaceholder(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
@@ -136,43 +169,56 @@ func Test_Latency(t *testing.T) {
 
 	app := fiber.New()
 	app.Use(New(Config{
-		Logger: &logger,
+		Logger:               &logger,
+		Fields:               []string{FieldBody, FieldResBody},
+		EmptyBodyPlaceholder: "<empty>",
 	}))
 
 	app.Get("/", func(c *fiber.Ctx) error {
-		time.Sleep(100 * time.Millisecond)
-		return c.SendStatus(fiber.StatusOK)
+		c.Status(fiber.StatusNoContent)
+		return nil
 	})
 
-	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
 	utils.AssertEqual(t, nil, err)
-	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
 
 	var logs map[string]any
 	_ = json.Unmarshal(buf.Bytes(), &logs)
 
-	latencyStr, ok := logs[FieldLatency].(string)
-	utils.AssertEqual(t, true, ok)
-	utils.AssertEqual(t, true, strings.Contains(latencyStr, "ms"))
-	utils.AssertEqual(t, float64(200), logs[FieldStatus])
+	utils.AssertEqual(t, "<empty>", logs[FieldBody])
+	utils.AssertEqual(t, "<empty>", logs[FieldResBody])
 }
 
-func Test_Logger_Next(t *testing.T) {
+func Test_EmptyBody_Omitted(t *testing.T) {
 	t.Parallel()
 
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
 	app := fiber.New()
 	app.Use(New(Config{
-		Next: func(_ *fiber.Ctx) bool {
-			return true
-		},
+		Logger: &logger,
+		Fields: []string{FieldBody, FieldResBody},
 	}))
 
-	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Status(fiber.StatusNoContent)
+		return nil
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
 	utils.AssertEqual(t, nil, err)
-	utils.AssertEqual(t, fiber.StatusNotFound, resp.StatusCode)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, bodyOk := logs[FieldBody]
+	_, resBodyOk := logs[FieldResBody]
+
+	utils.AssertEqual(t, false, bodyOk)
+	utils.AssertEqual(t, false, resBodyOk)
 }
 
-func Test_Logger_All(t *testing.T) {
+func Test_SkipResBody(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
@@ -180,63 +226,26 @@ func Test_Logger_All(t *testing.T) {
 
 	app := fiber.New()
 	app.Use(New(Config{
-		Logger: &logger,
-		Fields: []string{
-			FieldProtocol,
-			FieldPID,
-			FieldBody,
-			FieldIP,
-			FieldHost,
-			FieldURL,
-			FieldLatency,
-			FieldRoute,
-			FieldMethod,
-			FieldResBody,
-			FieldQueryParams,
-			FieldBytesReceived,
-			FieldBytesSent,
+		SkipResBody: func(_ *fiber.Ctx) bool {
+			return true
 		},
+		Logger: &logger,
+		Fields: []string{FieldResBody},
 	}))
 
-	app.Get("/", func(c *fiber.Ctx) error {
-		time.Sleep(100 * time.Millisecond)
-		return c.SendStatus(fiber.StatusNotFound)
-	})
-
-	resp, err := app.Test(httptest.NewRequest("GET", "/?foo=bar", nil))
+	body := bytes.NewReader([]byte("this is test"))
+	resp, err := app.Test(httptest.NewRequest("GET", "/", body))
 	utils.AssertEqual(t, nil, err)
 	utils.AssertEqual(t, fiber.StatusNotFound, resp.StatusCode)
 
-	expected := map[string]interface{}{
-		"body":          "",
-		"ip":            "0.0.0.0",
-		"host":          "example.com",
-		"url":           "/?foo=bar",
-		"level":         "warn",
-		"message":       "Client error",
-		"method":        "GET",
-		"route":         "/",
-		"protocol":      "http",
-		"pid":           float64(os.Getpid()),
-		"queryParams":   "foo=bar",
-		"resBody":       "Not Found",
-		"bytesReceived": float64(0),
-		"bytesSent":     float64(9),
-	}
-
 	var logs map[string]any
 	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldResBody]
 
-	for key, value := range expected {
-		utils.AssertEqual(t, value, logs[key])
-	}
-
-	latencyStr, ok := logs[FieldLatency].(string)
-	utils.AssertEqual(t, true, ok)
-	utils.AssertEqual(t, true, strings.Contains(latencyStr, "ms"))
+	utils.AssertEqual(t, false, ok)
 }
 
-func Test_Response_Body(t *testing.T) {
+func Test_Logger(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
@@ -245,25 +254,24 @@ func Test_Response_Body(t *testing.T) {
 	app := fiber.New()
 	app.Use(New(Config{
 		Logger: &logger,
-		Fields: []string{FieldResBody},
 	}))
 
-	expectedGetResponse := "Sample response body"
-
 	app.Get("/", func(c *fiber.Ctx) error {
-		return c.SendString(expectedGetResponse)
+		return errors.New("some random error")
 	})
 
-	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
 	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode)
 
 	var logs map[string]any
 	_ = json.Unmarshal(buf.Bytes(), &logs)
 
-	utils.AssertEqual(t, expectedGetResponse, logs[FieldResBody])
+	utils.AssertEqual(t, "some random error", logs[FieldError])
+	utils.AssertEqual(t, float64(500), logs[FieldStatus])
 }
 
-func Test_Request_Id(t *testing.T) {
+func Test_IgnoreErrors(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
@@ -272,27 +280,37 @@ func Test_Request_Id(t *testing.T) {
 	app := fiber.New()
 	app.Use(New(Config{
 		Logger: &logger,
-		Fields: []string{FieldRequestID},
+		IgnoreErrors: func(err error) bool {
+			return errors.Is(err, fiber.ErrNotFound)
+		},
 	}))
 
-	requestID := "bf985e8e-6a32-42ec-8e50-05a21db8f0e4"
-
 	app.Get("/", func(c *fiber.Ctx) error {
-		c.Response().Header.Set(fiber.HeaderXRequestID, requestID)
-		return c.SendString("hello")
+		return fiber.ErrNotFound
 	})
 
 	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
 	utils.AssertEqual(t, nil, err)
-	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, fiber.StatusNotFound, resp.StatusCode)
 
 	var logs map[string]any
 	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldError]
 
-	utils.AssertEqual(t, requestID, logs[FieldRequestID])
+	utils.AssertEqual(t, false, ok)
+	utils.AssertEqual(t, "warn", logs["level"])
+	utils.AssertEqual(t, float64(404), logs[FieldStatus])
 }
 
-func Test_Skip_URIs(t *testing.T) {
+type validationError struct {
+	Fields map[string]string
+}
+
+func (e *validationError) Error() string {
+	return "validation failed"
+}
+
+func Test_ErrorDetailFunc(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
@@ -300,21 +318,45 @@ func Test_Skip_URIs(t *testing.T) {
 
 	app := fiber.New()
 	app.Use(New(Config{
-		Logger:   &logger,
-		SkipURIs: []string{"/ignore_logging"},
+		Logger: &logger,
+		ErrorDetailFunc: func(err error) interface{} {
+			var validationErr *validationError
+			if errors.As(err, &validationErr) {
+				return validationErr.Fields
+			}
+			return nil
+		},
 	}))
 
-	app.Get("/ignore_logging", func(c *fiber.Ctx) error {
-		return errors.New("no log")
+	app.Get("/", func(c *fiber.Ctx) error {
+		return &validationError{Fields: map[string]string{"email": "required"}}
+	})
+	app.Get("/plain", func(c *fiber.Ctx) error {
+		return errors.New("some random error")
 	})
 
-	resp, err := app.Test(httptest.NewRequest("GET", "/ignore_logging", nil))
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
 	utils.AssertEqual(t, nil, err)
-	utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode)
-	utils.AssertEqual(t, 0, buf.Len())
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "validation failed", logs[FieldError])
+	utils.AssertEqual(t, map[string]interface{}{"email": "required"}, logs["errorDetails"])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/plain", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs["errorDetails"]
+
+	utils.AssertEqual(t, "some random error", logs[FieldError])
+	utils.AssertEqual(t, false, ok)
 }
 
-func Test_Req_Headers(t *testing.T) {
+func Test_Latency(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
@@ -323,36 +365,27 @@ func Test_Req_Headers(t *testing.T) {
 	app := fiber.New()
 	app.Use(New(Config{
 		Logger: &logger,
-		Fields: []string{FieldReqHeaders},
 	}))
 
 	app.Get("/", func(c *fiber.Ctx) error {
-		return c.SendString("hello")
+		time.Sleep(100 * time.Millisecond)
+		return c.SendStatus(fiber.StatusOK)
 	})
 
-	req := httptest.NewRequest("GET", "/", nil)
-	req.Header.Add("foo", "bar")
-	req.Header.Add("baz", "foo")
-
-	resp, err := app.Test(req)
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
 	utils.AssertEqual(t, nil, err)
 	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
 
-	expected := map[string]interface{}{
-		"Host":    "example.com",
-		"Baz":     "foo",
-		"Foo":     "bar",
-		"level":   "info",
-		"message": "Success",
-	}
-
 	var logs map[string]any
 	_ = json.Unmarshal(buf.Bytes(), &logs)
 
-	utils.AssertEqual(t, expected, logs)
+	latencyStr, ok := logs[FieldLatency].(string)
+	utils.AssertEqual(t, true, ok)
+	utils.AssertEqual(t, true, strings.Contains(latencyStr, "ms"))
+	utils.AssertEqual(t, float64(200), logs[FieldStatus])
 }
 
-func Test_Req_Headers_WrapHeaders(t *testing.T) {
+func Test_LatencySeconds(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
@@ -360,40 +393,31 @@ func Test_Req_Headers_WrapHeaders(t *testing.T) {
 
 	app := fiber.New()
 	app.Use(New(Config{
-		Logger:      &logger,
-		Fields:      []string{FieldReqHeaders},
-		WrapHeaders: true,
+		Logger: &logger,
+		Fields: []string{FieldLatency, FieldLatencySeconds},
 	}))
 
 	app.Get("/", func(c *fiber.Ctx) error {
-		return c.SendString("hello")
+		time.Sleep(100 * time.Millisecond)
+		return c.SendStatus(fiber.StatusOK)
 	})
 
-	req := httptest.NewRequest("GET", "/", nil)
-	req.Header.Add("foo", "bar")
-	req.Header.Add("baz", "foo")
-
-	resp, err := app.Test(req)
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
 	utils.AssertEqual(t, nil, err)
-	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
-
-	expected := map[string]interface{}{
-		"reqHeaders": map[string]interface{}{
-			"Host": "example.com",
-			"Baz":  "foo",
-			"Foo":  "bar",
-		},
-		"level":   "info",
-		"message": "Success",
-	}
 
 	var logs map[string]any
 	_ = json.Unmarshal(buf.Bytes(), &logs)
 
-	utils.AssertEqual(t, expected, logs)
+	seconds, ok := logs[FieldLatencySeconds].(float64)
+	utils.AssertEqual(t, true, ok)
+	utils.AssertEqual(t, true, seconds >= 0.1 && seconds < 1)
+
+	latencyStr, ok := logs[FieldLatency].(string)
+	utils.AssertEqual(t, true, ok)
+	utils.AssertEqual(t, true, strings.Contains(latencyStr, "ms"))
 }
 
-func Test_Res_Headers(t *testing.T) {
+func Test_LatencyPretty(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
@@ -402,36 +426,30 @@ func Test_Res_Headers(t *testing.T) {
 	app := fiber.New()
 	app.Use(New(Config{
 		Logger: &logger,
-		Fields: []string{FieldResHeaders},
+		Fields: []string{FieldLatencyPretty},
 	}))
 
 	app.Get("/", func(c *fiber.Ctx) error {
-		c.Set("foo", "bar")
-		c.Set("baz", "foo")
-		return c.SendString("hello")
+		time.Sleep(10 * time.Millisecond)
+		return c.SendStatus(fiber.StatusOK)
 	})
 
-	req := httptest.NewRequest("GET", "/", nil)
-
-	resp, err := app.Test(req)
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
 	utils.AssertEqual(t, nil, err)
-	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
-
-	expected := map[string]interface{}{
-		"Content-Type": "text/plain; charset=utf-8",
-		"Baz":          "foo",
-		"Foo":          "bar",
-		"level":        "info",
-		"message":      "Success",
-	}
 
 	var logs map[string]any
 	_ = json.Unmarshal(buf.Bytes(), &logs)
 
-	utils.AssertEqual(t, expected, logs)
+	pretty, ok := logs[FieldLatencyPretty].(string)
+	utils.AssertEqual(t, true, ok)
+	utils.AssertEqual(t, true, strings.HasSuffix(pretty, "ms"))
+
+	utils.AssertEqual(t, "850.00µs", prettyLatency(850*time.Microsecond))
+	utils.AssertEqual(t, "12.35ms", prettyLatency(12345*time.Microsecond))
+	utils.AssertEqual(t, "2.50s", prettyLatency(2500*time.Millisecond))
 }
 
-func Test_Res_Headers_WrapHeaders(t *testing.T) {
+func Test_LatencyAnomaly(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
@@ -439,195 +457,4388 @@ func Test_Res_Headers_WrapHeaders(t *testing.T) {
 
 	app := fiber.New()
 	app.Use(New(Config{
-		Logger:      &logger,
-		Fields:      []string{FieldResHeaders},
-		WrapHeaders: true,
+		Logger: &logger,
+		Fields: []string{FieldLatencyAnomaly},
 	}))
 
 	app.Get("/", func(c *fiber.Ctx) error {
-		c.Set("foo", "bar")
-		c.Set("baz", "foo")
+		if c.Query("slow") != "" {
+			time.Sleep(100 * time.Millisecond)
+		}
 		return c.SendString("hello")
 	})
 
-	req := httptest.NewRequest("GET", "/", nil)
+	for i := 0; i < 5; i++ {
+		buf.Reset()
+		_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		utils.AssertEqual(t, nil, err)
+	}
 
-	resp, err := app.Test(req)
-	utils.AssertEqual(t, nil, err)
-	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldLatencyAnomaly].(bool)
 
-	expected := map[string]interface{}{
-		"resHeaders": map[string]interface{}{
-			"Content-Type": "text/plain; charset=utf-8",
-			"Baz":          "foo",
-			"Foo":          "bar",
+	utils.AssertEqual(t, true, ok)
+
+	buf.Reset()
+	_, err := app.Test(httptest.NewRequest("GET", "/?slow=1", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, true, logs[FieldLatencyAnomaly])
+
+	tracker := newLatencyTracker()
+	anomaly, ok := tracker.observe("GET /", 10*time.Millisecond, 2)
+	utils.AssertEqual(t, true, ok)
+	utils.AssertEqual(t, false, anomaly)
+	anomaly, _ = tracker.observe("GET /", 15*time.Millisecond, 2)
+	utils.AssertEqual(t, false, anomaly)
+	anomaly, _ = tracker.observe("GET /", 30*time.Millisecond, 2)
+	utils.AssertEqual(t, true, anomaly)
+}
+
+func Test_DownstreamLatency(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			time.Sleep(50 * time.Millisecond)
+			return c.SendStatus(fiber.StatusInternalServerError)
 		},
-		"level":   "info",
-		"message": "Success",
-	}
+	})
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldLatency, FieldDownstreamLatency},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return errors.New("some random error")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
 
 	var logs map[string]any
 	_ = json.Unmarshal(buf.Bytes(), &logs)
 
-	utils.AssertEqual(t, expected, logs)
+	latency, err := time.ParseDuration(logs[FieldLatency].(string))
+	utils.AssertEqual(t, nil, err)
+	downstream, err := time.ParseDuration(logs[FieldDownstreamLatency].(string))
+	utils.AssertEqual(t, nil, err)
+
+	utils.AssertEqual(t, true, latency >= 50*time.Millisecond)
+	utils.AssertEqual(t, true, downstream < 50*time.Millisecond)
 }
 
-func Test_FieldsSnakeCase(t *testing.T) {
+func Test_Logger_Next(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Next: func(_ *fiber.Ctx) bool {
+			return true
+		},
+	}))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func Test_SkipFunc(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		SkipFunc: func(c *fiber.Ctx) bool {
+			return c.Response().StatusCode() == fiber.StatusOK
+		},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, 0, buf.Len())
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/missing", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusNotFound, resp.StatusCode)
+	utils.AssertEqual(t, true, buf.Len() > 0)
+}
+
+func Test_Logger_All(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
 	logger := zerolog.New(&buf)
 
 	app := fiber.New()
-	app.Use(requestid.New())
 	app.Use(New(Config{
 		Logger: &logger,
 		Fields: []string{
+			FieldProtocol,
+			FieldPID,
+			FieldBody,
+			FieldIP,
+			FieldHost,
+			FieldURL,
+			FieldLatency,
+			FieldRoute,
+			FieldMethod,
 			FieldResBody,
 			FieldQueryParams,
 			FieldBytesReceived,
 			FieldBytesSent,
-			FieldRequestID,
-			FieldResHeaders,
-			FieldReqHeaders,
 		},
-		FieldsSnakeCase: true,
-		WrapHeaders:     true,
 	}))
 
 	app.Get("/", func(c *fiber.Ctx) error {
-		c.Set("Foo", "bar")
-		return c.SendString("hello")
+		time.Sleep(100 * time.Millisecond)
+		return c.SendStatus(fiber.StatusNotFound)
 	})
 
-	req := httptest.NewRequest("GET", "/?param=value", nil)
-	req.Header.Add("X-Request-ID", "uuid")
-	req.Header.Add("Baz", "foo")
-
-	resp, err := app.Test(req)
+	resp, err := app.Test(httptest.NewRequest("GET", "/?foo=bar", nil))
 	utils.AssertEqual(t, nil, err)
-	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, fiber.StatusNotFound, resp.StatusCode)
 
 	expected := map[string]interface{}{
-		"bytes_received": float64(0),
-		"bytes_sent":     float64(5),
-		"query_params":   "param=value",
-		"req_headers": map[string]interface{}{
-			"Host":         "example.com",
-			"Baz":          "foo",
-			"X-Request-Id": "uuid",
-		},
-		"res_headers": map[string]interface{}{
-			"Content-Type": "text/plain; charset=utf-8",
-			"Foo":          "bar",
-			"X-Request-Id": "uuid",
-		},
-		"request_id": "uuid",
-		"res_body":   "hello",
-		"level":      "info",
-		"message":    "Success",
+		"body":          nil,
+		"ip":            "0.0.0.0",
+		"host":          "example.com",
+		"url":           "/?foo=bar",
+		"level":         "warn",
+		"message":       "Client error",
+		"method":        "GET",
+		"route":         "/",
+		"protocol":      "http",
+		"pid":           float64(os.Getpid()),
+		"queryParams":   "foo=bar",
+		"resBody":       "Not Found",
+		"bytesReceived": float64(0),
+		"bytesSent":     float64(9),
 	}
 
 	var logs map[string]any
 	_ = json.Unmarshal(buf.Bytes(), &logs)
 
-	utils.AssertEqual(t, expected, logs)
+	for key, value := range expected {
+		utils.AssertEqual(t, value, logs[key])
+	}
+
+	latencyStr, ok := logs[FieldLatency].(string)
+	utils.AssertEqual(t, true, ok)
+	utils.AssertEqual(t, true, strings.Contains(latencyStr, "ms"))
 }
 
-func Test_LoggerLevelsAndMessages(t *testing.T) {
+func Test_RedactQueryParams(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
 	logger := zerolog.New(&buf)
 
-	levels := []zerolog.Level{zerolog.ErrorLevel, zerolog.WarnLevel, zerolog.InfoLevel}
-	messages := []string{"server error", "client error", "success"}
-
 	app := fiber.New()
 	app.Use(New(Config{
-		Logger:   &logger,
-		Messages: messages,
-		Levels:   levels,
+		Logger:            &logger,
+		Fields:            []string{FieldURL, FieldQueryParams},
+		RedactQueryParams: []string{"apiKey"},
 	}))
 
-	app.Get("/200", func(c *fiber.Ctx) error {
-		c.Status(fiber.StatusOK)
-		return nil
-	})
-	app.Get("/400", func(c *fiber.Ctx) error {
-		c.Status(fiber.StatusBadRequest)
-		return nil
-	})
-	app.Get("/500", func(c *fiber.Ctx) error {
-		c.Status(fiber.StatusInternalServerError)
-		return nil
+	app.Get("/search", func(c *fiber.Ctx) error {
+		return c.SendString(c.Query("apiKey"))
 	})
 
-	tests := []struct {
-		Req     *http.Request
-		Status  int
-		Level   string
-		Message string
-	}{
-		{
-			Req:     httptest.NewRequest("GET", "/500", nil),
-			Status:  fiber.StatusInternalServerError,
-			Level:   levels[0].String(),
-			Message: messages[0],
-		},
-		{
-			Req:     httptest.NewRequest("GET", "/400", nil),
-			Status:  fiber.StatusBadRequest,
-			Level:   levels[1].String(),
-			Message: messages[1],
-		},
-		{
-			Req:     httptest.NewRequest("GET", "/200", nil),
-			Status:  fiber.StatusOK,
-			Level:   levels[2].String(),
-			Message: messages[2],
-		},
-	}
+	resp, err := app.Test(httptest.NewRequest("GET", "/search?q=fiber&apiKey=secret&apiKey=other", nil))
+	utils.AssertEqual(t, nil, err)
 
-	for _, test := range tests {
-		name := fmt.Sprintf("%s %s", test.Req.Method, test.Req.URL)
+	respBody, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "secret", string(respBody))
 
-		t.Run(name, func(t *testing.T) {
-			buf.Reset()
-			resp, err := app.Test(test.Req)
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
 
-			utils.AssertEqual(t, nil, err)
-			utils.AssertEqual(t, test.Status, resp.StatusCode)
+	utils.AssertEqual(t, "/search?q=fiber&apiKey=REDACTED&apiKey=REDACTED", logs[FieldURL])
+	utils.AssertEqual(t, "q=fiber&apiKey=REDACTED&apiKey=REDACTED", logs[FieldQueryParams])
 
-			var logs map[string]any
-			_ = json.Unmarshal(buf.Bytes(), &logs)
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/search?q=fiber", nil))
+	utils.AssertEqual(t, nil, err)
 
-			utils.AssertEqual(t, test.Level, logs["level"])
-			utils.AssertEqual(t, test.Message, logs["message"])
-		})
-	}
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "/search?q=fiber", logs[FieldURL])
 }
 
-func Test_Logger_FromContext(t *testing.T) {
+func Test_Response_Body(t *testing.T) {
 	t.Parallel()
 
 	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
 
 	app := fiber.New()
 	app.Use(New(Config{
-		GetLogger: func(c *fiber.Ctx) zerolog.Logger {
-			return zerolog.New(&buf).
-				With().
-				Str("foo", "bar").
-				Logger()
-		},
+		Logger: &logger,
+		Fields: []string{FieldResBody},
 	}))
 
+	expectedGetResponse := "Sample response body"
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(expectedGetResponse)
+	})
+
 	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
 	utils.AssertEqual(t, nil, err)
 
 	var logs map[string]any
 	_ = json.Unmarshal(buf.Bytes(), &logs)
 
-	utils.AssertEqual(t, "bar", logs["foo"])
+	utils.AssertEqual(t, expectedGetResponse, logs[FieldResBody])
+}
+
+func Test_Request_Id(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldRequestID},
+	}))
+
+	requestID := "bf985e8e-6a32-42ec-8e50-05a21db8f0e4"
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Response().Header.Set(fiber.HeaderXRequestID, requestID)
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, requestID, logs[FieldRequestID])
+}
+
+func Test_Request_Id_Header(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:          &logger,
+		Fields:          []string{FieldRequestID},
+		RequestIDHeader: "X-Correlation-ID",
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Correlation-ID", "correlation-id")
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "correlation-id", logs[FieldRequestID])
+}
+
+func Test_Skip_URIs(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:   &logger,
+		SkipURIs: []string{"/ignore_logging"},
+	}))
+
+	app.Get("/ignore_logging", func(c *fiber.Ctx) error {
+		return errors.New("no log")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/ignore_logging", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode)
+	utils.AssertEqual(t, 0, buf.Len())
+}
+
+func Test_Req_Headers(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldReqHeaders},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Add("foo", "bar")
+	req.Header.Add("baz", "foo")
+
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	expected := map[string]interface{}{
+		"Host":    "example.com",
+		"Baz":     "foo",
+		"Foo":     "bar",
+		"level":   "info",
+		"message": "Success",
+	}
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, expected, logs)
+}
+
+func Test_Req_Headers_WrapHeaders(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:      &logger,
+		Fields:      []string{FieldReqHeaders},
+		WrapHeaders: true,
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Add("foo", "bar")
+	req.Header.Add("baz", "foo")
+
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	expected := map[string]interface{}{
+		"reqHeaders": map[string]interface{}{
+			"Host": "example.com",
+			"Baz":  "foo",
+			"Foo":  "bar",
+		},
+		"level":   "info",
+		"message": "Success",
+	}
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, expected, logs)
+}
+
+func Test_Res_Headers(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldResHeaders},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set("foo", "bar")
+		c.Set("baz", "foo")
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	expected := map[string]interface{}{
+		"Content-Type": "text/plain; charset=utf-8",
+		"Baz":          "foo",
+		"Foo":          "bar",
+		"level":        "info",
+		"message":      "Success",
+	}
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, expected, logs)
+}
+
+func Test_Res_Headers_WrapHeaders(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:      &logger,
+		Fields:      []string{FieldResHeaders},
+		WrapHeaders: true,
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set("foo", "bar")
+		c.Set("baz", "foo")
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	expected := map[string]interface{}{
+		"resHeaders": map[string]interface{}{
+			"Content-Type": "text/plain; charset=utf-8",
+			"Baz":          "foo",
+			"Foo":          "bar",
+		},
+		"level":   "info",
+		"message": "Success",
+	}
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, expected, logs)
+}
+
+func Test_FieldsSnakeCase(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(requestid.New())
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{
+			FieldResBody,
+			FieldQueryParams,
+			FieldBytesReceived,
+			FieldBytesSent,
+			FieldRequestID,
+			FieldResHeaders,
+			FieldReqHeaders,
+		},
+		FieldsSnakeCase: true,
+		WrapHeaders:     true,
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set("Foo", "bar")
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/?param=value", nil)
+	req.Header.Add("X-Request-ID", "uuid")
+	req.Header.Add("Baz", "foo")
+
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	expected := map[string]interface{}{
+		"bytes_received": float64(0),
+		"bytes_sent":     float64(5),
+		"query_params":   "param=value",
+		"req_headers": map[string]interface{}{
+			"Host":         "example.com",
+			"Baz":          "foo",
+			"X-Request-Id": "uuid",
+		},
+		"res_headers": map[string]interface{}{
+			"Content-Type": "text/plain; charset=utf-8",
+			"Foo":          "bar",
+			"X-Request-Id": "uuid",
+		},
+		"request_id": "uuid",
+		"res_body":   "hello",
+		"level":      "info",
+		"message":    "Success",
+	}
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, expected, logs)
+}
+
+func Test_DryRun(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		DryRun: true,
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, 0, buf.Len())
+}
+
+func Test_NestUnderKey(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf).With().Str("service", "api").Logger()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:       &logger,
+		Fields:       []string{FieldMethod, FieldStatus, FieldError},
+		NestUnderKey: "http",
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return fiber.ErrBadRequest
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	expected := map[string]interface{}{
+		"service": "api",
+		"http": map[string]interface{}{
+			"method": "GET",
+			"status": float64(400),
+			"error":  "Bad Request",
+		},
+		"level":   "warn",
+		"message": "Client error",
+	}
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, expected, logs)
+}
+
+func Test_MaxErrorsPerSecond(t *testing.T) {
+	t.Parallel()
+
+	var buf syncBuffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:             &logger,
+		Fields:             []string{FieldStatus},
+		MaxErrorsPerSecond: 1,
+	}))
+
+	app.Get("/error", func(c *fiber.Ctx) error {
+		return errors.New("some random error")
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := app.Test(httptest.NewRequest("GET", "/error", nil))
+		utils.AssertEqual(t, nil, err)
+	}
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	// the summary is logged without further requests, by the next tick
+	var requests, suppressed int
+	for deadline := time.Now().Add(3 * time.Second); suppressed < 2 && time.Now().Before(deadline); {
+		time.Sleep(100 * time.Millisecond)
+
+		requests, suppressed = 0, 0
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var logs map[string]any
+			_ = json.Unmarshal([]byte(line), &logs)
+
+			if logs["message"] == "Error logs suppressed" {
+				utils.AssertEqual(t, "error", logs["level"])
+				suppressed += int(logs["suppressed"].(float64))
+			} else {
+				requests++
+			}
+		}
+	}
+
+	utils.AssertEqual(t, 2, requests)
+	utils.AssertEqual(t, 2, suppressed)
+}
+
+// syncBuffer is a bytes.Buffer safe for logs written by background goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func Test_LoggerLevelsAndMessages(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	levels := []zerolog.Level{zerolog.ErrorLevel, zerolog.WarnLevel, zerolog.InfoLevel}
+	messages := []string{"server error", "client error", "success"}
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:   &logger,
+		Messages: messages,
+		Levels:   levels,
+	}))
+
+	app.Get("/200", func(c *fiber.Ctx) error {
+		c.Status(fiber.StatusOK)
+		return nil
+	})
+	app.Get("/400", func(c *fiber.Ctx) error {
+		c.Status(fiber.StatusBadRequest)
+		return nil
+	})
+	app.Get("/500", func(c *fiber.Ctx) error {
+		c.Status(fiber.StatusInternalServerError)
+		return nil
+	})
+
+	tests := []struct {
+		Req     *http.Request
+		Status  int
+		Level   string
+		Message string
+	}{
+		{
+			Req:     httptest.NewRequest("GET", "/500", nil),
+			Status:  fiber.StatusInternalServerError,
+			Level:   levels[0].String(),
+			Message: messages[0],
+		},
+		{
+			Req:     httptest.NewRequest("GET", "/400", nil),
+			Status:  fiber.StatusBadRequest,
+			Level:   levels[1].String(),
+			Message: messages[1],
+		},
+		{
+			Req:     httptest.NewRequest("GET", "/200", nil),
+			Status:  fiber.StatusOK,
+			Level:   levels[2].String(),
+			Message: messages[2],
+		},
+	}
+
+	for _, test := range tests {
+		name := fmt.Sprintf("%s %s", test.Req.Method, test.Req.URL)
+
+		t.Run(name, func(t *testing.T) {
+			buf.Reset()
+			resp, err := app.Test(test.Req)
+
+			utils.AssertEqual(t, nil, err)
+			utils.AssertEqual(t, test.Status, resp.StatusCode)
+
+			var logs map[string]any
+			_ = json.Unmarshal(buf.Bytes(), &logs)
+
+			utils.AssertEqual(t, test.Level, logs["level"])
+			utils.AssertEqual(t, test.Message, logs["message"])
+		})
+	}
+}
+
+func Test_Hooks(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	var levels []zerolog.Level
+	hook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, message string) {
+		levels = append(levels, level)
+		e.Bool("alerted", level >= zerolog.ErrorLevel)
+	})
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Hooks:  []zerolog.Hook{hook},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return errors.New("some random error")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, []zerolog.Level{zerolog.ErrorLevel}, levels)
+	utils.AssertEqual(t, true, logs["alerted"])
+}
+
+func Test_LevelFromResponseHeader(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:                  &logger,
+		LevelFromResponseHeader: "X-Log-Level",
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set("X-Log-Level", c.Query("level"))
+		return c.SendString("hello")
+	})
+
+	tests := []struct {
+		Level    string
+		Expected string
+	}{
+		{Level: "warn", Expected: "warn"},
+		{Level: "invalid", Expected: "info"},
+		{Level: "", Expected: "info"},
+		// would panic or exit the process
+		{Level: "panic", Expected: "info"},
+		{Level: "fatal", Expected: "info"},
+	}
+
+	for _, test := range tests {
+		buf.Reset()
+		resp, err := app.Test(httptest.NewRequest("GET", "/?level="+test.Level, nil))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, "", resp.Header.Get("X-Log-Level"))
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, test.Expected, logs["level"])
+	}
+}
+
+func Test_LevelFromResponseHeader_NotLogged(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:                  &logger,
+		LevelFromResponseHeader: "X-Log-Level",
+		Next: func(c *fiber.Ctx) bool {
+			return c.Path() == "/next"
+		},
+		SkipURIs: []string{"/skip-uri"},
+		SkipFunc: func(c *fiber.Ctx) bool {
+			return c.Path() == "/skip-func"
+		},
+	}))
+
+	app.Get("/*", func(c *fiber.Ctx) error {
+		c.Set("X-Log-Level", "warn")
+		return c.SendString("hello")
+	})
+
+	for _, path := range []string{"/next", "/skip-uri", "/skip-func"} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, "", resp.Header.Get("X-Log-Level"), path)
+	}
+	utils.AssertEqual(t, 0, buf.Len())
+}
+
+func Test_Logger_FromContext(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	app := fiber.New()
+	app.Use(New(Config{
+		GetLogger: func(c *fiber.Ctx) zerolog.Logger {
+			return zerolog.New(&buf).
+				With().
+				Str("foo", "bar").
+				Logger()
+		},
+	}))
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "bar", logs["foo"])
+}
+
+func Test_Colo(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldColo},
+		ColoResolver: func(c *fiber.Ctx) string {
+			return c.Get("X-Colo")
+		},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Colo", "ams")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "ams", logs[FieldColo])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldColo]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_StreamChunks(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldStatus, FieldStreamChunks},
+	}))
+
+	app.Get("/stream", func(c *fiber.Ctx) error {
+		return SendStreamWriter(c, func(w *bufio.Writer) {
+			for i := 0; i < 3; i++ {
+				_, _ = fmt.Fprintf(w, "data: %d\n\n", i)
+				_ = w.Flush()
+			}
+		})
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/stream", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(200), logs[FieldStatus])
+	utils.AssertEqual(t, float64(3), logs[FieldStreamChunks])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(0), logs[FieldStreamChunks])
+}
+
+func Test_BytesSentActual(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldBytesSentActual},
+	}))
+
+	app.Get("/stream", func(c *fiber.Ctx) error {
+		return SendStream(c, strings.NewReader(strings.Repeat("a", 10000)))
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/stream", nil))
+	utils.AssertEqual(t, nil, err)
+	_, err = io.Copy(io.Discard, resp.Body)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(10000), logs[FieldBytesSentActual])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(5), logs[FieldBytesSentActual])
+}
+
+func Test_TTFB(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldLatency, FieldTTFB},
+	}))
+
+	app.Get("/stream", func(c *fiber.Ctx) error {
+		return SendStreamWriter(c, func(w *bufio.Writer) {
+			time.Sleep(50 * time.Millisecond)
+			_, _ = w.WriteString("data: 0\n\n")
+			_ = w.Flush()
+		})
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/stream", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	ttfb, err := time.ParseDuration(logs[FieldTTFB].(string))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, true, ttfb >= 50*time.Millisecond)
+
+	// the handler returns before the stream is written
+	latency, err := time.ParseDuration(logs[FieldLatency].(string))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, true, latency < ttfb)
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	_, ok := logs[FieldTTFB]
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_Origin(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldOrigin, FieldAllowOrigin},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		if c.Get(fiber.HeaderOrigin) == "https://allowed.example" {
+			c.Set(fiber.HeaderAccessControlAllowOrigin, "https://allowed.example")
+		}
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderOrigin, "https://allowed.example")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "https://allowed.example", logs[FieldOrigin])
+	utils.AssertEqual(t, "https://allowed.example", logs[FieldAllowOrigin])
+
+	buf.Reset()
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderOrigin, "https://blocked.example")
+	_, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldAllowOrigin]
+
+	utils.AssertEqual(t, "https://blocked.example", logs[FieldOrigin])
+	utils.AssertEqual(t, false, ok)
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok = logs[FieldOrigin]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_AcceptLanguage(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldAcceptLanguage},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderAcceptLanguage, "de-CH, de;q=0.9")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "de-CH, de;q=0.9", logs[FieldAcceptLanguage])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldAcceptLanguage]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_AppName_Hostname(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New(fiber.Config{
+		AppName: "test-app",
+	})
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldAppName, FieldHostname},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	hostname, _ := os.Hostname()
+	utils.AssertEqual(t, "test-app", logs[FieldAppName])
+	utils.AssertEqual(t, hostname, logs[FieldHostname])
+}
+
+func Test_ReqSizeClass(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:         &logger,
+		Fields:         []string{FieldReqSizeClass},
+		ReqSizeBuckets: []int{4, 8, 16},
+	}))
+
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	tests := []struct {
+		Body  string
+		Class string
+	}{
+		{Body: "", Class: "tiny"},
+		{Body: "12345", Class: "small"},
+		{Body: "123456789", Class: "medium"},
+		{Body: "12345678901234567", Class: "large"},
+	}
+
+	for _, test := range tests {
+		buf.Reset()
+		_, err := app.Test(httptest.NewRequest("POST", "/", strings.NewReader(test.Body)))
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, test.Class, logs[FieldReqSizeClass])
+	}
+}
+
+func Test_ReqSizeBuckets_Invalid(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		utils.AssertEqual(t, "fiberzerolog: ReqSizeBuckets must be in ascending order", recover())
+	}()
+
+	New(Config{
+		ReqSizeBuckets: []int{8, 4, 16},
+	})
+}
+
+func Test_CorrelationID(t *testing.T) {
+	t.Parallel()
+
+	type correlationKey struct{}
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:                &logger,
+		Fields:                []string{FieldCorrelationID},
+		ContextCorrelationKey: correlationKey{},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.SetUserContext(context.WithValue(c.UserContext(), correlationKey{}, "correlation-id"))
+		return c.SendString("hello")
+	})
+	app.Get("/int", func(c *fiber.Ctx) error {
+		c.SetUserContext(context.WithValue(c.UserContext(), correlationKey{}, 42))
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "correlation-id", logs[FieldCorrelationID])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/int", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldCorrelationID]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_ResponseTimeUnix(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldResponseTimeUnix},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	before := time.Now().UnixMilli()
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	after := time.Now().UnixMilli()
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	responseTime, ok := logs[FieldResponseTimeUnix].(float64)
+	utils.AssertEqual(t, true, ok)
+	utils.AssertEqual(t, true, int64(responseTime) >= before && int64(responseTime) <= after)
+}
+
+func Test_ClientVersion(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldClientVersion},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Client-Version", "4.2.0")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "4.2.0", logs[FieldClientVersion])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldClientVersion]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_CacheControl(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldCacheControl},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, c.Query("cc"))
+		return c.SendString("hello")
+	})
+
+	tests := []struct {
+		CacheControl string
+		Expected     interface{}
+	}{
+		{
+			CacheControl: `public, max-age=3600, no-cache="Set-Cookie, Foo"`,
+			Expected: map[string]interface{}{
+				"public":   true,
+				"max-age":  float64(3600),
+				"no-cache": "Set-Cookie, Foo",
+			},
+		},
+		{
+			CacheControl: `max-age="3600`,
+			Expected:     `max-age="3600`,
+		},
+	}
+
+	for _, test := range tests {
+		buf.Reset()
+		req := httptest.NewRequest("GET", "/?cc="+url.QueryEscape(test.CacheControl), nil)
+		_, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, test.Expected, logs[FieldCacheControl])
+	}
+}
+
+func Test_RequestLine(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:            &logger,
+		Fields:            []string{FieldRequestLine},
+		RedactQueryParams: []string{"token"},
+	}))
+
+	app.Get("/path", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/path?x=1&token=secret", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "GET /path?x=1&token=REDACTED HTTP/1.1", logs[FieldRequestLine])
+}
+
+func Test_SessionID(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:            &logger,
+		Fields:            []string{FieldSessionID},
+		SessionCookieName: "sid",
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	var hashedBuf bytes.Buffer
+	hashedLogger := zerolog.New(&hashedBuf)
+
+	hashedApp := fiber.New()
+	hashedApp.Use(New(Config{
+		Logger:            &hashedLogger,
+		Fields:            []string{FieldSessionID},
+		SessionCookieName: "sid",
+		HashSessionID:     true,
+	}))
+	hashedApp.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "session-value"})
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "session-value", logs[FieldSessionID])
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "session-value"})
+	_, err = hashedApp.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(hashedBuf.Bytes(), &logs)
+
+	sum := sha256.Sum256([]byte("session-value"))
+	utils.AssertEqual(t, hex.EncodeToString(sum[:]), logs[FieldSessionID])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldSessionID]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_Claims(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldClaims},
+		JWTClaimsFunc: func(c *fiber.Ctx) map[string]interface{} {
+			if c.Query("user") == "" {
+				return nil
+			}
+			return map[string]interface{}{
+				"sub":   c.Query("user"),
+				"scope": "read",
+			}
+		},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/?user=42", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, map[string]interface{}{"sub": "42", "scope": "read"}, logs[FieldClaims])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldClaims]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_ConnRequestNum(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+	})
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldConnRequestNum},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	ln := fasthttputil.NewInmemoryListener()
+	go func() {
+		_ = app.Listener(ln)
+	}()
+	defer func() {
+		_ = app.Shutdown()
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
+	}
+
+	for i := 1; i <= 2; i++ {
+		buf.Reset()
+		resp, err := client.Get("http://example.com/")
+		utils.AssertEqual(t, nil, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, float64(i), logs[FieldConnRequestNum])
+	}
+}
+
+func Test_Env(t *testing.T) {
+	t.Setenv("FIBERZEROLOG_TEST_ENV", "staging")
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldEnv},
+		EnvVar: "FIBERZEROLOG_TEST_ENV",
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "staging", logs[FieldEnv])
+}
+
+func Test_BuildVersion(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:       &logger,
+		Fields:       []string{FieldBuildVersion},
+		BuildVersion: "v1.2.3",
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "v1.2.3", logs[FieldBuildVersion])
+
+	buf.Reset()
+	app = fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldBuildVersion},
+	}))
+
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldBuildVersion]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_ContentLength(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldContentLength},
+	}))
+
+	app.Get("/stream", func(c *fiber.Ctx) error {
+		return c.SendStream(strings.NewReader("hello world"), 11)
+	})
+	app.Get("/chunked", func(c *fiber.Ctx) error {
+		return c.SendStream(strings.NewReader("hello world"), -1)
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/stream", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(11), logs[FieldContentLength])
+
+	// set from the body by fasthttp
+	buf.Reset()
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(5), logs[FieldContentLength])
+	utils.AssertEqual(t, "5", resp.Header.Get(fiber.HeaderContentLength))
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/chunked", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldContentLength]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_Scheme_ForwardedProto(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldScheme, FieldForwardedProto},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderXForwardedProto, "https")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "http", logs[FieldScheme])
+	utils.AssertEqual(t, "https", logs[FieldForwardedProto])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldForwardedProto]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_FieldTypes(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldRequestID, FieldStatus, FieldClientVersion, FieldMethod},
+		FieldTypes: map[string]FieldType{
+			FieldRequestID:     FieldTypeInt,
+			FieldStatus:        FieldTypeString,
+			FieldClientVersion: FieldTypeFloat,
+			FieldMethod:        FieldTypeBool,
+		},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderXRequestID, "12345")
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Client-Version", "4.2")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(12345), logs[FieldRequestID])
+	utils.AssertEqual(t, "200", logs[FieldStatus])
+	utils.AssertEqual(t, 4.2, logs[FieldClientVersion])
+	// not a bool, keeps its own type
+	utils.AssertEqual(t, "GET", logs[FieldMethod])
+}
+
+func Test_LogfmtWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: WithLogfmtWriter(&buf),
+		Fields: []string{FieldMethod, FieldPath, FieldStatus, FieldUserAgent},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderUserAgent, "my agent")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	line := regexp.MustCompile(`^time=\S+ level=info message=Success method=GET path=/ status=200 ua="my agent"\n$`)
+	utils.AssertEqual(t, true, line.MatchString(buf.String()), buf.String())
+}
+
+func Test_DebugSkips(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:     &logger,
+		DebugSkips: true,
+		SkipURIs:   []string{"/health"},
+		SkipFunc: func(c *fiber.Ctx) bool {
+			return c.Response().StatusCode() == fiber.StatusNotModified
+		},
+	}))
+
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/cached", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNotModified)
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/health", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "debug", logs["level"])
+	utils.AssertEqual(t, "skipURIs", logs["skipReason"])
+	utils.AssertEqual(t, "/health", logs[FieldPath])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/cached", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "skipFunc", logs["skipReason"])
+}
+
+func Test_DebugSkips_SnakeCase(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:          &logger,
+		DebugSkips:      true,
+		FieldsSnakeCase: true,
+		SkipURIs:        []string{"/health"},
+	}))
+
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/health", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "skipURIs", logs["skip_reason"])
+}
+
+func Test_HeaderCount(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldReqHeaderCount, FieldResHeaderCount},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set("X-One", "1")
+		c.Set("X-Two", "2")
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-A", "a")
+	req.Header.Set("X-B", "b")
+	req.Header.Set("X-C", "c")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	reqCount, ok := logs[FieldReqHeaderCount].(float64)
+	utils.AssertEqual(t, true, ok)
+	// Host and the test client headers are counted too
+	utils.AssertEqual(t, true, reqCount >= 3)
+
+	resCount, ok := logs[FieldResHeaderCount].(float64)
+	utils.AssertEqual(t, true, ok)
+	// Content-Type is set by SendString
+	utils.AssertEqual(t, true, resCount >= 3)
+}
+
+func Test_MaxEventBytes(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:        &logger,
+		Fields:        []string{FieldStatus, FieldBody, FieldUserAgent},
+		MaxEventBytes: 200,
+	}))
+
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("a", 1000)))
+	req.Header.Set(fiber.HeaderUserAgent, "test")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	utils.AssertEqual(t, true, buf.Len() <= 200)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldBody]
+
+	utils.AssertEqual(t, false, ok)
+	utils.AssertEqual(t, "test", logs[FieldUserAgent])
+	utils.AssertEqual(t, float64(200), logs[FieldStatus])
+	utils.AssertEqual(t, true, logs["eventTrimmed"])
+
+	buf.Reset()
+	req = httptest.NewRequest("POST", "/", strings.NewReader("small"))
+	_, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok = logs["eventTrimmed"]
+
+	utils.AssertEqual(t, "small", logs[FieldBody])
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_ProtoMajorMinor(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldProtocol, FieldProtoMajor, FieldProtoMinor},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(1), logs[FieldProtoMajor])
+	utils.AssertEqual(t, float64(1), logs[FieldProtoMinor])
+
+	major, minor, ok := parseProto("HTTP/2")
+	utils.AssertEqual(t, true, ok)
+	utils.AssertEqual(t, 2, major)
+	utils.AssertEqual(t, 0, minor)
+
+	_, _, ok = parseProto("SPDY/3")
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_RemoveFields(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:       &logger,
+		RemoveFields: []string{FieldError, FieldIP},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return errors.New("some random error")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, errorOk := logs[FieldError]
+	_, ipOk := logs[FieldIP]
+
+	utils.AssertEqual(t, false, errorOk)
+	utils.AssertEqual(t, false, ipOk)
+	utils.AssertEqual(t, float64(500), logs[FieldStatus])
+	utils.AssertEqual(t, "GET", logs[FieldMethod])
+	utils.AssertEqual(t, []string{FieldIP, FieldLatency, FieldStatus, FieldMethod, FieldURL, FieldError}, ConfigDefault.Fields)
+
+	cfg := configDefault(Config{
+		Fields:       []string{FieldPath, FieldStatus},
+		RemoveFields: []string{FieldStatus},
+	})
+	utils.AssertEqual(t, []string{FieldPath}, cfg.Fields)
+}
+
+func Test_IdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldIdempotencyKey},
+	}))
+
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Idempotency-Key", "8e03978e-40d5-43e8-bc93-6894a57f9324")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "8e03978e-40d5-43e8-bc93-6894a57f9324", logs[FieldIdempotencyKey])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("POST", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldIdempotencyKey]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_LevelWriters(t *testing.T) {
+	t.Parallel()
+
+	var buf, errBuf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldStatus},
+		LevelWriters: map[zerolog.Level]io.Writer{
+			zerolog.ErrorLevel: &errBuf,
+		},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+	app.Get("/error", func(c *fiber.Ctx) error {
+		return errors.New("some random error")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/error", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(errBuf.Bytes(), &logs)
+
+	utils.AssertEqual(t, 0, buf.Len())
+	utils.AssertEqual(t, "error", logs["level"])
+	utils.AssertEqual(t, float64(500), logs[FieldStatus])
+
+	errBuf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, 0, errBuf.Len())
+	utils.AssertEqual(t, "info", logs["level"])
+}
+
+func Test_CanonicalPath(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldPath, FieldCanonicalPath},
+	}))
+
+	app.Get("/*", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/Users//42/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "/Users//42/", logs[FieldPath])
+	utils.AssertEqual(t, "/users/42", logs[FieldCanonicalPath])
+
+	utils.AssertEqual(t, "/", canonicalPath("/"))
+	utils.AssertEqual(t, "/", canonicalPath("//"))
+	utils.AssertEqual(t, "/a/b", canonicalPath("a/B"))
+}
+
+func Test_RouteMethods(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldRouteMethods},
+	}))
+
+	handler := func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	}
+	app.Get("/users/:id", handler)
+	app.Put("/users/:id", handler)
+	app.Delete("/users/:id", handler)
+	app.Post("/users", handler)
+
+	_, err := app.Test(httptest.NewRequest("PUT", "/users/42", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	// fiber registers HEAD with GET
+	utils.AssertEqual(t, []any{"GET", "HEAD", "PUT", "DELETE"}, logs[FieldRouteMethods])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/unknown", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldRouteMethods]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_Sampler(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:  &logger,
+		Fields:  []string{FieldStatus},
+		Sampler: &zerolog.BasicSampler{N: 2},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	for i := 0; i < 4; i++ {
+		_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		utils.AssertEqual(t, nil, err)
+	}
+
+	utils.AssertEqual(t, 2, strings.Count(buf.String(), "\n"))
+}
+
+func Test_AuthScheme(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldAuthScheme},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer secret-token")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "Bearer", logs[FieldAuthScheme])
+	utils.AssertEqual(t, false, strings.Contains(buf.String(), "secret-token"))
+
+	// a header without scheme may be a bare credential
+	buf.Reset()
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "secret-token")
+	_, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldAuthScheme]
+
+	utils.AssertEqual(t, false, ok)
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok = logs[FieldAuthScheme]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_SizeRatio(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldSizeRatio},
+	}))
+
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendString(strings.Repeat("a", 100))
+	})
+
+	_, err := app.Test(httptest.NewRequest("POST", "/", strings.NewReader("1234")))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(25), logs[FieldSizeRatio])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("POST", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldSizeRatio]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_ChannelWriter(t *testing.T) {
+	t.Parallel()
+
+	w, lines := NewChannelWriter(1)
+	logger := zerolog.New(w)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldStatus, FieldPath},
+	}))
+
+	app.Get("/*", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	go func() {
+		for _, path := range []string{"/a", "/b"} {
+			_, _ = app.Test(httptest.NewRequest("GET", path, nil))
+		}
+	}()
+
+	for _, path := range []string{"/a", "/b"} {
+		var logs map[string]any
+		_ = json.Unmarshal(<-lines, &logs)
+
+		utils.AssertEqual(t, path, logs[FieldPath])
+		utils.AssertEqual(t, float64(200), logs[FieldStatus])
+	}
+}
+
+func Test_RateLimitKey(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	keyGenerator := func(c *fiber.Ctx) string {
+		return "tenant:" + c.Get("X-Tenant")
+	}
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:           &logger,
+		Fields:           []string{FieldRateLimitKey},
+		RateLimitKeyFunc: keyGenerator,
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusTooManyRequests)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Tenant", "acme")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "tenant:acme", logs[FieldRateLimitKey])
+}
+
+func Test_HostPort(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldHostPort},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "http://api.example.com:8443/", nil)
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "api.example.com:8443", logs[FieldHostPort])
+
+	// app.Test listens on 0.0.0.0:0
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "http://api.example.com/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "api.example.com", logs[FieldHostPort])
+}
+
+func Test_FieldsByStatusClass(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldStatus},
+		FieldsByStatusClass: map[string][]string{
+			"5xx": {FieldStatus, FieldBody, FieldError},
+		},
+	}))
+
+	app.Post("/", func(c *fiber.Ctx) error {
+		if c.Query("fail") != "" {
+			return errors.New("some random error")
+		}
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("POST", "/?fail=1", strings.NewReader("payload")))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(500), logs[FieldStatus])
+	utils.AssertEqual(t, "payload", logs[FieldBody])
+	utils.AssertEqual(t, "some random error", logs[FieldError])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("POST", "/", strings.NewReader("payload")))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldBody]
+
+	utils.AssertEqual(t, float64(200), logs[FieldStatus])
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_ClientTimezone(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:               &logger,
+		Fields:               []string{FieldClientTimezone},
+		ClientTimezoneHeader: "X-Timezone",
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Timezone", "Europe/Paris")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "Europe/Paris", logs[FieldClientTimezone])
+
+	cfg := configDefault(Config{})
+	utils.AssertEqual(t, "X-Client-Timezone", cfg.ClientTimezoneHeader)
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldClientTimezone]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_FieldOrder(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf).With().Str("service", "api").Logger()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:     &logger,
+		Fields:     []string{FieldMethod, FieldReqHeaders, FieldStatus, FieldPath},
+		FieldOrder: []string{FieldPath, FieldStatus, "X-Custom"},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Custom", "v")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	prefix := `{"level":"info","service":"api","path":"/","status":200,"X-Custom":"v","method":"GET",`
+	utils.AssertEqual(t, true, strings.HasPrefix(buf.String(), prefix), buf.String())
+
+	var logs map[string]any
+	utils.AssertEqual(t, nil, json.Unmarshal(buf.Bytes(), &logs))
+	utils.AssertEqual(t, "Success", logs["message"])
+}
+
+func Test_BodyType(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldBodyType},
+	}))
+
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"a":1}`))
+	req.Header.Set(fiber.HeaderContentType, "application/json; charset=utf-8")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "json", logs[FieldBodyType])
+
+	utils.AssertEqual(t, "empty", bodyType(fiber.MIMEApplicationJSON, 0))
+	utils.AssertEqual(t, "json", bodyType("application/problem+json", 1))
+	utils.AssertEqual(t, "form", bodyType(fiber.MIMEApplicationForm, 1))
+	utils.AssertEqual(t, "multipart", bodyType("multipart/form-data; boundary=x", 1))
+	utils.AssertEqual(t, "text", bodyType("text/plain", 1))
+	utils.AssertEqual(t, "text", bodyType("application/atom+xml", 1))
+	utils.AssertEqual(t, "binary", bodyType("image/png", 1))
+	utils.AssertEqual(t, "binary", bodyType("", 1))
+}
+
+func Test_RecoverConfigFuncs(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:             &logger,
+		Fields:             []string{FieldStatus, FieldResBody, FieldClaims},
+		RecoverConfigFuncs: true,
+		SkipBody: func(_ *fiber.Ctx) bool {
+			panic("buggy SkipBody")
+		},
+		GetResBody: func(_ *fiber.Ctx) []byte {
+			panic("buggy GetResBody")
+		},
+		JWTClaimsFunc: func(_ *fiber.Ctx) map[string]interface{} {
+			return map[string]interface{}{"sub": "42"}
+		},
+		TypeMarshalers: map[reflect.Type]func(interface{}) []byte{
+			reflect.TypeOf(map[string]interface{}{}): func(interface{}) []byte {
+				panic("buggy marshaler")
+			},
+		},
+		Sampler: panicSampler{},
+		Hooks: []zerolog.Hook{
+			zerolog.HookFunc(func(e *zerolog.Event, _ zerolog.Level, _ string) {
+				e.Str("hooked", "yes")
+				panic("buggy hook")
+			}),
+		},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	utils.AssertEqual(t, 5, len(lines))
+
+	var warning map[string]any
+	_ = json.Unmarshal([]byte(lines[0]), &warning)
+
+	utils.AssertEqual(t, "warn", warning["level"])
+	utils.AssertEqual(t, "GetResBody", warning["func"])
+	utils.AssertEqual(t, "buggy GetResBody", warning["panic"])
+
+	warning = nil
+	_ = json.Unmarshal([]byte(lines[1]), &warning)
+
+	utils.AssertEqual(t, "TypeMarshalers", warning["func"])
+	utils.AssertEqual(t, "buggy marshaler", warning["panic"])
+
+	warning = nil
+	_ = json.Unmarshal([]byte(lines[2]), &warning)
+
+	utils.AssertEqual(t, "Sampler", warning["func"])
+	utils.AssertEqual(t, "buggy sampler", warning["panic"])
+
+	warning = nil
+	_ = json.Unmarshal([]byte(lines[3]), &warning)
+
+	utils.AssertEqual(t, "Hooks", warning["func"])
+	utils.AssertEqual(t, "buggy hook", warning["panic"])
+
+	var logs map[string]any
+	_ = json.Unmarshal([]byte(lines[4]), &logs)
+
+	utils.AssertEqual(t, float64(200), logs[FieldStatus])
+	utils.AssertEqual(t, "hello", logs[FieldResBody])
+	// logged with Interface
+	utils.AssertEqual(t, map[string]any{"sub": "42"}, logs[FieldClaims])
+	utils.AssertEqual(t, "yes", logs["hooked"])
+}
+
+type panicSampler struct{}
+
+func (panicSampler) Sample(zerolog.Level) bool {
+	panic("buggy sampler")
+}
+
+func Test_Event(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldEvent},
+	}))
+
+	app.Post("/login", func(c *fiber.Ctx) error {
+		c.Locals("event", "user.login")
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "user.login", logs[FieldEvent])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldEvent]
+
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_Compression(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldAcceptEncoding, FieldResponseCompressed},
+	}))
+	app.Use(compress.New())
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(strings.Repeat("hello", 100))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "gzip")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "gzip", logs[FieldAcceptEncoding])
+	utils.AssertEqual(t, true, logs[FieldResponseCompressed])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs[FieldAcceptEncoding]
+
+	utils.AssertEqual(t, false, ok)
+	utils.AssertEqual(t, false, logs[FieldResponseCompressed])
+}
+
+func Test_Decorate(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldStatus},
+		Decorate: func(c *fiber.Ctx, zc zerolog.Context) zerolog.Context {
+			return zc.Strs("roles", []string{"admin", "dev"}).Dict("user", zerolog.Dict().Int("id", 42))
+		},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(200), logs[FieldStatus])
+	utils.AssertEqual(t, []any{"admin", "dev"}, logs["roles"])
+	utils.AssertEqual(t, map[string]any{"id": float64(42)}, logs["user"])
+}
+
+func Test_ProxyDuration(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldProxyDuration},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set("X-Response-Time", c.Query("t"))
+		return c.SendString("hello")
+	})
+
+	for value, expected := range map[string]any{
+		"12.5ms": "12.5ms",
+		"250":    "250ms",
+		"slow":   nil,
+	} {
+		buf.Reset()
+		_, err := app.Test(httptest.NewRequest("GET", "/?t="+value, nil))
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, expected, logs[FieldProxyDuration], value)
+	}
+}
+
+func Test_LogRequestStart(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:          &logger,
+		Fields:          []string{FieldStatus},
+		LogRequestStart: true,
+		SkipURIs:        []string{"/health"},
+	}))
+
+	app.Get("/*", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/users?id=1", nil))
+	utils.AssertEqual(t, nil, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	utils.AssertEqual(t, 2, len(lines))
+
+	var start map[string]any
+	_ = json.Unmarshal([]byte(lines[0]), &start)
+
+	utils.AssertEqual(t, "debug", start["level"])
+	utils.AssertEqual(t, "Request started", start["message"])
+	utils.AssertEqual(t, "GET", start[FieldMethod])
+	utils.AssertEqual(t, "/users?id=1", start[FieldURL])
+
+	var logs map[string]any
+	_ = json.Unmarshal([]byte(lines[1]), &logs)
+
+	utils.AssertEqual(t, "info", logs["level"])
+	utils.AssertEqual(t, float64(200), logs[FieldStatus])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/health", nil))
+	utils.AssertEqual(t, nil, err)
+
+	utils.AssertEqual(t, 0, buf.Len())
+}
+
+// testCert returns a certificate for cn signed by parent, self-signed if parent is nil.
+func testCert(t *testing.T, cn string, parent *tls.Certificate, usage x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	utils.AssertEqual(t, nil, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := template, interface{}(key)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	utils.AssertEqual(t, nil, err)
+	leaf, err := x509.ParseCertificate(der)
+	utils.AssertEqual(t, nil, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func Test_ClientCertCN(t *testing.T) {
+	t.Parallel()
+
+	ca := testCert(t, "test-ca", nil, x509.ExtKeyUsageAny)
+	serverCert := testCert(t, "example.com", &ca, x509.ExtKeyUsageServerAuth)
+	clientCert := testCert(t, "client-42", &ca, x509.ExtKeyUsageClientAuth)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+	})
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldClientCertCN},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	ln := fasthttputil.NewInmemoryListener()
+	go func() {
+		_ = app.Listener(tls.NewListener(ln, &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientCAs:    pool,
+			ClientAuth:   tls.VerifyClientCertIfGiven,
+			MinVersion:   tls.VersionTLS12,
+		}))
+	}()
+	defer func() {
+		_ = app.Shutdown()
+	}()
+
+	for _, certs := range [][]tls.Certificate{{clientCert}, nil} {
+		buf.Reset()
+		client := &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+					return ln.Dial()
+				},
+				TLSClientConfig: &tls.Config{
+					Certificates: certs,
+					RootCAs:      pool,
+					MinVersion:   tls.VersionTLS12,
+				},
+			},
+		}
+		resp, err := client.Get("https://example.com/")
+		utils.AssertEqual(t, nil, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+		cn, ok := logs[FieldClientCertCN]
+
+		if certs != nil {
+			utils.AssertEqual(t, "client-42", cn)
+		} else {
+			utils.AssertEqual(t, false, ok)
+		}
+	}
+}
+
+func Test_ObserveLatency(t *testing.T) {
+	t.Parallel()
+
+	var (
+		routes  []string
+		seconds []float64
+	)
+
+	logger := zerolog.Nop()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		ObserveLatency: func(route string, s float64) {
+			routes = append(routes, route)
+			seconds = append(seconds, s)
+		},
+		SkipFunc: func(c *fiber.Ctx) bool {
+			return true
+		},
+	}))
+
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		time.Sleep(10 * time.Millisecond)
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/users/42", nil))
+	utils.AssertEqual(t, nil, err)
+
+	utils.AssertEqual(t, []string{"/users/:id"}, routes)
+	utils.AssertEqual(t, true, seconds[0] >= 0.01)
+}
+
+func Test_DeprecatedRoutes(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:             &logger,
+		Fields:             []string{FieldStatus},
+		DeprecatedRoutes:   []string{"/v1/users/:id"},
+		DeprecatedMinLevel: zerolog.WarnLevel,
+	}))
+
+	handler := func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	}
+	app.Get("/v1/users/:id", handler)
+	app.Get("/v2/users/:id", handler)
+
+	_, err := app.Test(httptest.NewRequest("GET", "/v1/users/42", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, true, logs["deprecated"])
+	utils.AssertEqual(t, "warn", logs["level"])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/v2/users/42", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_, ok := logs["deprecated"]
+
+	utils.AssertEqual(t, false, ok)
+	utils.AssertEqual(t, "info", logs["level"])
+}
+
+func Test_ReqTotalBytes(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldBytesReceived, FieldReqTotalBytes},
+	}))
+
+	var headerSize int
+	app.Post("/", func(c *fiber.Ctx) error {
+		headerSize = len(c.Request().Header.Header())
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("this is test"))
+	req.Header.Set("X-Large", strings.Repeat("a", 100))
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(12), logs[FieldBytesReceived])
+	utils.AssertEqual(t, true, headerSize > 100)
+	utils.AssertEqual(t, float64(headerSize+12), logs[FieldReqTotalBytes])
+}
+
+func Test_LogID(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:          &logger,
+		LogID:           true,
+		LogRequestStart: true,
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	utils.AssertEqual(t, 2, len(lines))
+
+	ids := make([]string, 0, len(lines))
+	for _, line := range lines {
+		var logs map[string]any
+		_ = json.Unmarshal([]byte(line), &logs)
+
+		id, ok := logs["logId"].(string)
+		utils.AssertEqual(t, true, ok)
+		utils.AssertEqual(t, 16, len(id))
+		ids = append(ids, id)
+	}
+	utils.AssertEqual(t, true, ids[0] != ids[1])
+}
+
+func Test_ParamNames(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldParamNames},
+	}))
+
+	app.Get("/users/:id/posts/:post", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/users/42/posts/7", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, []any{"id", "post"}, logs[FieldParamNames])
+	utils.AssertEqual(t, false, strings.Contains(buf.String(), "42"))
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, []any{}, logs[FieldParamNames])
+}
+
+func Test_LogOnlyIfSlowerThan(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:              &logger,
+		LogOnlyIfSlowerThan: 50 * time.Millisecond,
+	}))
+
+	app.Get("/fast", func(c *fiber.Ctx) error {
+		return c.SendString("fast")
+	})
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		time.Sleep(60 * time.Millisecond)
+		return c.SendString("slow")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/fast", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, 0, buf.Len())
+
+	_, err = app.Test(httptest.NewRequest("GET", "/slow", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "/slow", logs[FieldURL])
+}
+
+type mapStorage struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (s *mapStorage) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[key], nil
+}
+
+func (s *mapStorage) Set(key string, val []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = val
+	return nil
+}
+
+func (s *mapStorage) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+func (s *mapStorage) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = map[string][]byte{}
+	return nil
+}
+
+func (*mapStorage) Close() error {
+	return nil
+}
+
+func (s *mapStorage) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.data)
+}
+
+func Test_Storage(t *testing.T) {
+	t.Parallel()
+
+	var logged, drained bytes.Buffer
+	logger := zerolog.New(&logged)
+	storage := &mapStorage{data: map[string][]byte{}}
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:             &logger,
+		Fields:             []string{FieldPath},
+		Storage:            storage,
+		AsyncWriter:        &drained,
+		AsyncFlushInterval: time.Hour,
+	}))
+
+	app.Get("/*", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	for _, path := range []string{"/a", "/b"} {
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		utils.AssertEqual(t, nil, err)
+	}
+
+	utils.AssertEqual(t, 0, logged.Len())
+	utils.AssertEqual(t, 2, storage.len())
+
+	// the buffer is drained on shutdown
+	_ = app.Shutdown()
+
+	utils.AssertEqual(t, 0, storage.len())
+	lines := strings.Split(strings.TrimSpace(drained.String()), "\n")
+	utils.AssertEqual(t, 2, len(lines))
+
+	var logs map[string]any
+	_ = json.Unmarshal([]byte(lines[1]), &logs)
+
+	utils.AssertEqual(t, "/b", logs[FieldPath])
+}
+
+func Test_Storage_Shared(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.New(io.Discard)
+	storage := &mapStorage{data: map[string][]byte{}}
+
+	apps := make([]*fiber.App, 2)
+	drained := make([]*bytes.Buffer, 2)
+	for i, path := range []string{"/a", "/b"} {
+		drained[i] = &bytes.Buffer{}
+		apps[i] = fiber.New()
+		apps[i].Use(New(Config{
+			Logger:             &logger,
+			Fields:             []string{FieldPath},
+			Storage:            storage,
+			AsyncWriter:        drained[i],
+			AsyncFlushInterval: time.Hour,
+		}))
+		apps[i].Get("/*", func(c *fiber.Ctx) error {
+			return c.SendString("hello")
+		})
+
+		_, err := apps[i].Test(httptest.NewRequest("GET", path, nil))
+		utils.AssertEqual(t, nil, err)
+	}
+
+	utils.AssertEqual(t, 2, storage.len())
+
+	// each buffer only drains its own logs
+	for i, path := range []string{"/a", "/b"} {
+		_ = apps[i].Shutdown()
+
+		utils.AssertEqual(t, 1-i, storage.len())
+		lines := strings.Split(strings.TrimSpace(drained[i].String()), "\n")
+		utils.AssertEqual(t, 1, len(lines))
+
+		var logs map[string]any
+		_ = json.Unmarshal([]byte(lines[0]), &logs)
+
+		utils.AssertEqual(t, path, logs[FieldPath])
+	}
+}
+
+// failDeleteStorage is a mapStorage whose deletes fail while failing is 1.
+type failDeleteStorage struct {
+	*mapStorage
+	failing int32
+}
+
+func (s *failDeleteStorage) Delete(key string) error {
+	if atomic.LoadInt32(&s.failing) == 1 {
+		return errors.New("delete failed")
+	}
+	return s.mapStorage.Delete(key)
+}
+
+func Test_Storage_FailedDelete(t *testing.T) {
+	t.Parallel()
+
+	var drained syncBuffer
+	logger := zerolog.New(io.Discard)
+	storage := &failDeleteStorage{mapStorage: &mapStorage{data: map[string][]byte{}}, failing: 1}
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:             &logger,
+		Fields:             []string{FieldPath},
+		Storage:            storage,
+		AsyncWriter:        &drained,
+		AsyncFlushInterval: 50 * time.Millisecond,
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	time.Sleep(200 * time.Millisecond)
+	utils.AssertEqual(t, 1, strings.Count(drained.String(), "\n"))
+	utils.AssertEqual(t, 1, storage.len())
+
+	// the delete is retried without writing the log again
+	atomic.StoreInt32(&storage.failing, 0)
+	time.Sleep(200 * time.Millisecond)
+	utils.AssertEqual(t, 1, strings.Count(drained.String(), "\n"))
+	utils.AssertEqual(t, 0, storage.len())
+
+	_ = app.Shutdown()
+}
+
+func Test_Storage_RequiresAsyncWriter(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		utils.AssertEqual(t, "fiberzerolog: Storage requires AsyncWriter", recover())
+	}()
+
+	New(Config{Storage: &mapStorage{data: map[string][]byte{}}})
+}
+
+func Test_ResponseError(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldResponseError},
+		AppErrorExtractor: func(c *fiber.Ctx) (bool, string) {
+			var payload struct {
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(c.Response().Body(), &payload); err != nil || payload.Error == "" {
+				return false, ""
+			}
+			return true, payload.Error
+		},
+		AppErrorMinLevel: zerolog.WarnLevel,
+	}))
+
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"error": "quota exceeded"})
+	})
+	app.Get("/ok", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"result": 1})
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/fail", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "quota exceeded", logs[FieldResponseError])
+	utils.AssertEqual(t, "warn", logs[zerolog.LevelFieldName])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/ok", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	_, ok := logs[FieldResponseError]
+	utils.AssertEqual(t, false, ok)
+	utils.AssertEqual(t, "info", logs[zerolog.LevelFieldName])
+}
+
+func Test_ConsoleWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: WithConsoleWriter(&buf),
+		Fields: []string{FieldUserAgent, FieldURL, FieldStatus, FieldMethod},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/?a=1", nil)
+	req.Header.Set(fiber.HeaderUserAgent, "test")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	// levels are colored
+	utils.AssertEqual(t, true, strings.Contains(buf.String(), "\x1b["))
+
+	line := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(buf.String(), "")
+	utils.AssertEqual(t, true, regexp.MustCompile(`^\d+:\d\d[AP]M INF GET 200 /\?a=1 Success ua=test\n$`).MatchString(line), line)
+}
+
+type validationDetails struct {
+	Field string
+}
+
+func Test_TypeMarshalers(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldError, FieldClaims},
+		ErrorDetailFunc: func(err error) interface{} {
+			return validationDetails{Field: "email"}
+		},
+		JWTClaimsFunc: func(c *fiber.Ctx) map[string]interface{} {
+			return map[string]interface{}{"sub": "42"}
+		},
+		TypeMarshalers: map[reflect.Type]func(interface{}) []byte{
+			reflect.TypeOf(validationDetails{}): func(v interface{}) []byte {
+				return []byte(`{"field":"` + v.(validationDetails).Field + `"}`)
+			},
+		},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return errors.New("invalid")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, map[string]any{"field": "email"}, logs["errorDetails"])
+	// unregistered types fall back to Interface
+	utils.AssertEqual(t, map[string]any{"sub": "42"}, logs[FieldClaims])
+}
+
+func Test_SlowClient(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:              &logger,
+		Fields:              []string{FieldSlowClient},
+		SlowClientThreshold: 30 * time.Millisecond,
+	}))
+
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		return SendStreamWriter(c, func(w *bufio.Writer) {
+			_, _ = w.WriteString("data: 0\n\n")
+			_ = w.Flush()
+			time.Sleep(50 * time.Millisecond)
+			_, _ = w.WriteString("data: 1\n\n")
+			_ = w.Flush()
+		})
+	})
+	app.Get("/fast", func(c *fiber.Ctx) error {
+		return SendStream(c, strings.NewReader("hello"))
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	for path, expected := range map[string]any{"/slow": true, "/fast": false, "/": nil} {
+		buf.Reset()
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, expected, logs[FieldSlowClient], path)
+	}
+}
+
+func Test_DownstreamCalls(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldDownstreamCalls},
+	}))
+
+	app.Get("/int", func(c *fiber.Ctx) error {
+		c.Locals("downstreamCalls", 3)
+		return c.SendString("hello")
+	})
+	app.Get("/counter", func(c *fiber.Ctx) error {
+		calls := new(int64)
+		c.Locals("downstreamCalls", calls)
+		atomic.AddInt64(calls, 2)
+		return c.SendString("hello")
+	})
+	app.Get("/invalid", func(c *fiber.Ctx) error {
+		c.Locals("downstreamCalls", "3")
+		return c.SendString("hello")
+	})
+
+	for path, expected := range map[string]any{"/int": float64(3), "/counter": float64(2), "/invalid": nil} {
+		buf.Reset()
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, expected, logs[FieldDownstreamCalls], path)
+	}
+}
+
+func Test_Fingerprint(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New(fiber.Config{
+		ProxyHeader: fiber.HeaderXForwardedFor,
+	})
+	app.Use(New(Config{
+		Logger:          &logger,
+		Fields:          []string{FieldFingerprint},
+		FingerprintFunc: DefaultFingerprint,
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	fingerprint := func(ip, ua string) any {
+		buf.Reset()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(fiber.HeaderXForwardedFor, ip)
+		req.Header.Set(fiber.HeaderUserAgent, ua)
+		_, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+		return logs[FieldFingerprint]
+	}
+
+	first := fingerprint("203.0.113.7", "bot")
+	utils.AssertEqual(t, 16, len(first.(string)))
+	// same subnet
+	utils.AssertEqual(t, first, fingerprint("203.0.113.99", "bot"))
+	utils.AssertEqual(t, false, first == fingerprint("198.51.100.7", "bot"))
+	utils.AssertEqual(t, false, first == fingerprint("203.0.113.7", "browser"))
+}
+
+func Test_WithCaller(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:     &logger,
+		WithCaller: true,
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	caller, _ := logs[zerolog.CallerFieldName].(string)
+	utils.AssertEqual(t, true, regexp.MustCompile(`fiberzerolog/zerolog\.go:\d+$`).MatchString(caller), caller)
+}
+
+func Test_ServerTiming(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldServerTiming},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderServerTiming, c.Query("timing"))
+		return c.SendString("hello")
+	})
+
+	for timing, expected := range map[string]any{
+		`db;dur=53, app;dur=47.2`:                 map[string]any{"db": float64(53), "app": 47.2},
+		`cache;desc="Cache, Read";dur=23.2, miss`: map[string]any{"cache": 23.2, "miss": float64(0)},
+		`db;dur=fast`:                             `db;dur=fast`,
+		``:                                        nil,
+	} {
+		buf.Reset()
+		_, err := app.Test(httptest.NewRequest("GET", "/?timing="+url.QueryEscape(timing), nil))
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, expected, logs[FieldServerTiming], timing)
+	}
+}
+
+func Test_EnabledFunc(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	var enabled int32 = 1
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		EnabledFunc: func() bool {
+			return atomic.LoadInt32(&enabled) == 1
+		},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, true, buf.Len() > 0)
+
+	atomic.StoreInt32(&enabled, 0)
+	buf.Reset()
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, 0, buf.Len())
+}
+
+func Test_SetResponseTimeHeader(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:                &logger,
+		Fields:                []string{FieldLatency},
+		SetResponseTimeHeader: true,
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, logs[FieldLatency], resp.Header.Get("X-Response-Time"))
+}
+
+func Test_SetResponseTimeHeader_ProxyDuration(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:                &logger,
+		Fields:                []string{FieldProxyDuration},
+		SetResponseTimeHeader: true,
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	// the measured latency is not logged back as the proxy duration
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	_, ok := logs[FieldProxyDuration]
+	utils.AssertEqual(t, false, ok)
+
+	// the request header still is
+	buf.Reset()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Response-Time", "12.5ms")
+	_, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "12.5ms", logs[FieldProxyDuration])
+}
+
+func Test_ParseJSONBody(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:           &logger,
+		Fields:           []string{FieldBody},
+		ParseJSONBody:    true,
+		MaxArrayElements: 2,
+	}))
+
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	for _, tc := range []struct {
+		contentType, body, expected string
+	}{
+		{fiber.MIMEApplicationJSON, `{"z":[1,2,3,4],"a":{"b":[[1,2,3],"x"]},"n":1}`, `{"z":[1,2,"...+2 more"],"a":{"b":[[1,2,"...+1 more"],"x"]},"n":1}`},
+		{fiber.MIMEApplicationJSON, `[{"id":1}]`, `[{"id":1}]`},
+		{fiber.MIMEApplicationJSON, `"text"`, `"text"`},
+		{fiber.MIMEApplicationJSON, `{"invalid"`, `"{\"invalid\""`},
+		{fiber.MIMETextPlain, `[1,2,3]`, `"[1,2,3]"`},
+	} {
+		buf.Reset()
+		req := httptest.NewRequest("POST", "/", strings.NewReader(tc.body))
+		req.Header.Set(fiber.HeaderContentType, tc.contentType)
+		_, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]json.RawMessage
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, tc.expected, string(logs[FieldBody]), tc.body)
+	}
+}
+
+func Test_ConnID(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+	})
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldConnID},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	ln := fasthttputil.NewInmemoryListener()
+	go func() {
+		_ = app.Listener(ln)
+	}()
+	defer func() {
+		_ = app.Shutdown()
+	}()
+
+	newClient := func() *http.Client {
+		return &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+					return ln.Dial()
+				},
+			},
+		}
+	}
+
+	connID := func(client *http.Client) any {
+		buf.Reset()
+		resp, err := client.Get("http://example.com/")
+		utils.AssertEqual(t, nil, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+		return logs[FieldConnID]
+	}
+
+	client := newClient()
+	first := connID(client)
+	utils.AssertEqual(t, true, first != nil)
+	// same keep-alive connection
+	utils.AssertEqual(t, first, connID(client))
+	utils.AssertEqual(t, false, first == connID(newClient()))
+}
+
+func Test_SizeMismatch(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldSizeMismatch},
+	}))
+
+	app.Get("/truncated", func(c *fiber.Ctx) error {
+		return SendStream(c, strings.NewReader("hello"), 10)
+	})
+	app.Get("/sized", func(c *fiber.Ctx) error {
+		return SendStream(c, strings.NewReader("hello"), 5)
+	})
+	app.Get("/chunked", func(c *fiber.Ctx) error {
+		return SendStream(c, strings.NewReader("hello"))
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	for path, expected := range map[string]map[string]any{
+		"/truncated": {FieldSizeMismatch: true, "declaredBytes": float64(10), FieldBytesSentActual: float64(5)},
+		"/sized":     {FieldSizeMismatch: false},
+		"/chunked":   {},
+		"/":          {FieldSizeMismatch: false},
+	} {
+		buf.Reset()
+		// fasthttp reports the truncated body stream
+		_, _ = app.Test(httptest.NewRequest("GET", path, nil))
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		for _, key := range []string{FieldSizeMismatch, "declaredBytes", FieldBytesSentActual} {
+			utils.AssertEqual(t, expected[key], logs[key], path+" "+key)
+		}
+	}
+}
+
+func Test_Subdomain(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:     &logger,
+		Fields:     []string{FieldSubdomain},
+		BaseDomain: "example.co.uk",
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	for host, expected := range map[string]any{
+		"acme.example.co.uk":         "acme",
+		"eu.Acme.example.co.uk:8080": "eu.acme",
+		"example.co.uk":              nil,
+		"acme.other.com":             nil,
+	} {
+		buf.Reset()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Host = host
+		_, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, expected, logs[FieldSubdomain], host)
+	}
+}
+
+func Test_ResponseID(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldRequestID, FieldResponseID},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		if c.Query("async") != "" {
+			c.Set("X-Response-ID", "res-1")
+		}
+		return c.SendString("hello")
+	})
+
+	req := httptest.NewRequest("GET", "/?async=1", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "req-1")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "req-1", logs[FieldRequestID])
+	utils.AssertEqual(t, "res-1", logs[FieldResponseID])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	_, ok := logs[FieldResponseID]
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_ErrorCode(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return c.SendStatus(fiber.StatusServiceUnavailable)
+		},
+	})
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldStatus, FieldErrorCode},
+	}))
+
+	app.Get("/fiber", func(c *fiber.Ctx) error {
+		return fmt.Errorf("lookup: %w", fiber.ErrNotFound)
+	})
+	app.Get("/other", func(c *fiber.Ctx) error {
+		return errors.New("failed")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/fiber", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, float64(fiber.StatusNotFound), logs[FieldErrorCode])
+	utils.AssertEqual(t, float64(fiber.StatusServiceUnavailable), logs[FieldStatus])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/other", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	_, ok := logs[FieldErrorCode]
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_Replayable(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldReplayable},
+	}))
+
+	app.All("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	for _, tc := range []struct {
+		method, key string
+		expected    bool
+	}{
+		{"GET", "", true},
+		{"PUT", "", true},
+		{"DELETE", "", true},
+		{"POST", "", false},
+		{"PATCH", "", false},
+		{"POST", "key-1", true},
+	} {
+		buf.Reset()
+		req := httptest.NewRequest(tc.method, "/", nil)
+		if tc.key != "" {
+			req.Header.Set("Idempotency-Key", tc.key)
+		}
+		_, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, tc.expected, logs[FieldReplayable], tc.method+" "+tc.key)
+	}
+}
+
+func Test_Range(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldRange, FieldContentRange},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		if c.Get(fiber.HeaderRange) != "" {
+			c.Set(fiber.HeaderContentRange, "bytes 0-4/11")
+			return c.Status(fiber.StatusPartialContent).SendString("hello")
+		}
+		return c.SendString("hello world")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderRange, "bytes=0-4")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "bytes=0-4", logs[FieldRange])
+	utils.AssertEqual(t, "bytes 0-4/11", logs[FieldContentRange])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	_, ok := logs[FieldRange]
+	utils.AssertEqual(t, false, ok)
+	_, ok = logs[FieldContentRange]
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_Coalesce(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:   &logger,
+		Fields:   []string{FieldMethod, FieldRoute, FieldStatus, FieldURL},
+		Coalesce: time.Hour,
+	}))
+
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "0" {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+		return c.SendString("hello")
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/0", "/users/3"} {
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		utils.AssertEqual(t, nil, err)
+	}
+	utils.AssertEqual(t, 0, buf.Len())
+
+	// the groups are flushed on shutdown
+	_ = app.Shutdown()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	utils.AssertEqual(t, 2, len(lines))
+
+	var logs map[string]any
+	_ = json.Unmarshal([]byte(lines[0]), &logs)
+	utils.AssertEqual(t, "/users/1", logs[FieldURL])
+	utils.AssertEqual(t, float64(3), logs["count"])
+
+	logs = nil
+	_ = json.Unmarshal([]byte(lines[1]), &logs)
+	utils.AssertEqual(t, float64(fiber.StatusNotFound), logs[FieldStatus])
+	utils.AssertEqual(t, float64(1), logs["count"])
+}
+
+func Test_TenantID(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldTenantID},
+		TenantResolver: func(c *fiber.Ctx) string {
+			if tenant := c.Get("X-Tenant-ID"); tenant != "" {
+				return tenant
+			}
+			if host := c.Hostname(); strings.HasSuffix(host, ".example.com") {
+				return strings.TrimSuffix(host, ".example.com")
+			}
+			return ""
+		},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	for _, tc := range []struct {
+		host, header string
+		expected     any
+	}{
+		{"acme.example.com", "", "acme"},
+		{"acme.example.com", "globex", "globex"},
+		{"example.com", "", nil},
+	} {
+		buf.Reset()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Host = tc.host
+		if tc.header != "" {
+			req.Header.Set("X-Tenant-ID", tc.header)
+		}
+		_, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, tc.expected, logs[FieldTenantID], tc.host+" "+tc.header)
+	}
+}
+
+func Test_CacheHit(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	type cacheKey struct{}
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:      &logger,
+		Fields:      []string{FieldCacheHit},
+		CacheHitKey: cacheKey{},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		if c.Query("cached") != "" {
+			c.Locals(cacheKey{}, true)
+		}
+		return c.SendString("hello")
+	})
+
+	for path, expected := range map[string]bool{"/?cached=1": true, "/": false} {
+		buf.Reset()
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, expected, logs[FieldCacheHit], path)
+	}
+}
+
+func Test_BodyAuditWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf, audit bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:          &logger,
+		Fields:          []string{FieldStatus, FieldBody, FieldResBody},
+		BodyAuditWriter: &audit,
+	}))
+
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendString("secret response")
+	})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("secret request"))
+	req.Header.Set(fiber.HeaderXRequestID, "req-1")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs, audited map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+	_ = json.Unmarshal(audit.Bytes(), &audited)
+
+	utils.AssertEqual(t, false, strings.Contains(buf.String(), "secret"))
+	utils.AssertEqual(t, float64(200), logs[FieldStatus])
+	utils.AssertEqual(t, 16, len(logs["auditId"].(string)))
+
+	utils.AssertEqual(t, logs["auditId"], audited["auditId"])
+	utils.AssertEqual(t, "req-1", audited[FieldRequestID])
+	utils.AssertEqual(t, "secret request", audited[FieldBody])
+	utils.AssertEqual(t, "secret response", audited[FieldResBody])
+}
+
+func Test_BodyAuditWriter_NotLogged(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name   string
+		Config Config
+	}{
+		{Name: "sampler", Config: Config{Sampler: &zerolog.BasicSampler{N: 2}}},
+		{Name: "coalesce", Config: Config{Coalesce: time.Hour}},
+	}
+
+	for _, test := range tests {
+		var buf, audit bytes.Buffer
+		logger := zerolog.New(&buf)
+
+		cfg := test.Config
+		cfg.Logger = &logger
+		cfg.Fields = []string{FieldBody}
+		cfg.BodyAuditWriter = &audit
+
+		app := fiber.New()
+		app.Use(New(cfg))
+
+		app.Post("/", func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		for _, body := range []string{"first", "second"} {
+			_, err := app.Test(httptest.NewRequest("POST", "/", strings.NewReader(body)))
+			utils.AssertEqual(t, nil, err)
+		}
+		_ = app.Shutdown()
+
+		// a single main event, so a single audit line
+		var logs, audited map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+		_ = json.Unmarshal(audit.Bytes(), &audited)
+
+		utils.AssertEqual(t, 1, strings.Count(audit.String(), "\n"), test.Name)
+		utils.AssertEqual(t, "first", audited[FieldBody], test.Name)
+		utils.AssertEqual(t, logs["auditId"], audited["auditId"], test.Name)
+	}
+}
+
+func Test_Timings(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:           &logger,
+		Fields:           []string{FieldTimings},
+		TimingLocalsKeys: []string{"db", "cache", "external"},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		if c.Query("timed") != "" {
+			c.Locals("db", 12*time.Millisecond)
+			c.Locals("cache", "fast")
+		}
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/?timed=1", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, map[string]any{"db": "12ms"}, logs[FieldTimings])
+
+	buf.Reset()
+	_, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	logs = nil
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	_, ok := logs[FieldTimings]
+	utils.AssertEqual(t, false, ok)
+}
+
+func Test_DebugCookie(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldStatus},
+		FieldsByStatusClass: map[string][]string{
+			"5xx": {FieldStatus, FieldResBody},
+		},
+		Levels:      []zerolog.Level{zerolog.ErrorLevel, zerolog.WarnLevel, zerolog.NoLevel},
+		DebugCookie: "debug_7f3a",
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, 0, buf.Len())
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "debug_7f3a", Value: "1"})
+	_, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "trace", logs[zerolog.LevelFieldName])
+	utils.AssertEqual(t, float64(200), logs[FieldStatus])
+	utils.AssertEqual(t, "hello", logs[FieldResBody])
+}
+
+func Test_StatusText(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger: &logger,
+		Fields: []string{FieldStatus, FieldStatusText},
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+	app.Get("/custom", func(c *fiber.Ctx) error {
+		return c.SendStatus(599)
+	})
+
+	for path, expected := range map[string]any{"/": "OK", "/missing": "Not Found", "/custom": nil} {
+		buf.Reset()
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		utils.AssertEqual(t, nil, err)
+
+		var logs map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &logs)
+
+		utils.AssertEqual(t, expected, logs[FieldStatusText], path)
+	}
+}
+
+func Test_DebugCookie_MaxEventBytes(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Logger:        &logger,
+		Fields:        []string{FieldStatus, FieldResBody},
+		MaxEventBytes: 100,
+		DebugCookie:   "debug_7f3a",
+	}))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(strings.Repeat("a", 500))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "debug_7f3a", Value: "1"})
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	utils.AssertEqual(t, true, buf.Len() <= 100, buf.String())
+
+	var logs map[string]any
+	_ = json.Unmarshal(buf.Bytes(), &logs)
+
+	utils.AssertEqual(t, "trace", logs[zerolog.LevelFieldName])
+	utils.AssertEqual(t, true, logs["eventTrimmed"])
+	_, ok := logs[FieldResBody]
+	utils.AssertEqual(t, false, ok)
 }